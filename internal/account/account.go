@@ -0,0 +1,64 @@
+package account
+
+import (
+	"errors"
+	"time"
+)
+
+type Type string
+type Status string
+
+const (
+	TypeChecking Type = "checking"
+	TypeSavings  Type = "savings"
+
+	// StatusFrozen accounts may still receive money but cannot pay out
+	StatusActive Status = "active"
+	StatusFrozen Status = "frozen"
+	StatusClosed Status = "closed"
+)
+
+var (
+	ErrAccountNotFound = errors.New("account not found")
+	ErrAccountInactive = errors.New("account is not active")
+	ErrAccountNotEmpty = errors.New("account still has balances, active holds or a credit limit")
+	ErrUserNotFound    = errors.New("user not found")
+)
+
+// Account is a customer account. A user may hold many; each has its own IBAN
+// and a balance per currency.
+type Account struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"index;not null"`
+	Type      Type       `json:"type" gorm:"size:20;not null"`
+	Status    Status     `json:"status" gorm:"size:20;not null;index"`
+	IBAN      string     `json:"iban" gorm:"column:iban;size:34;uniqueIndex;not null"`
+	Name      string     `json:"name,omitempty" gorm:"size:100"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty"`
+}
+
+func (Account) TableName() string { return "accounts" }
+
+// CanDebit reports whether money may leave the account
+func (a Account) CanDebit() bool {
+	return a.Status == StatusActive
+}
+
+// CanCredit reports whether money may arrive on the account
+func (a Account) CanCredit() bool {
+	return a.Status == StatusActive || a.Status == StatusFrozen
+}
+
+// OpenAccountRequest opens an account. Only admins may open one for another
+// user.
+type OpenAccountRequest struct {
+	UserID uint   `json:"user_id"`
+	Type   Type   `json:"type" binding:"required,oneof=checking savings"`
+	Name   string `json:"name" binding:"max=100"`
+}
+
+type UpdateStatusRequest struct {
+	Status Status `json:"status" binding:"required,oneof=active frozen closed"`
+}
@@ -0,0 +1,181 @@
+package account
+
+import (
+	"bankapi/internal/balance"
+	"bankapi/internal/middleware"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct{}
+
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// ListAccounts returns the caller's accounts. Admins may pass ?user_id or
+// omit it to list every account.
+func (h *Handler) ListAccounts(c *gin.Context) {
+	u, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Kimlik doğrulaması gerekli"})
+		return
+	}
+	userID := u.ID
+	if u.IsAdmin() {
+		userID = 0
+		if v := c.Query("user_id"); v != "" {
+			id, err := strconv.ParseUint(v, 10, 64)
+			if err != nil || id == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "user_id geçersiz"})
+				return
+			}
+			userID = uint(id)
+		}
+	}
+	accounts, err := List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "hesaplar getirilemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, accounts)
+}
+
+// OpenAccount opens a checking or savings account
+func (h *Handler) OpenAccount(c *gin.Context) {
+	u, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Kimlik doğrulaması gerekli"})
+		return
+	}
+	var req OpenAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.UserID == 0 {
+		req.UserID = u.ID
+	}
+	if req.UserID != u.ID && !u.IsAdmin() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Erişim reddedildi"})
+		return
+	}
+	a, err := Open(req.UserID, req.Type, req.Name)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, a)
+}
+
+// GetAccount returns an account with its balances
+func (h *Handler) GetAccount(c *gin.Context) {
+	a, ok := h.loadOwned(c)
+	if !ok {
+		return
+	}
+	balances, err := balance.GetBalances(a.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "bakiyeler getirilemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"account": a, "balances": balances})
+}
+
+// UpdateStatus freezes, reactivates or closes an account
+func (h *Handler) UpdateStatus(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var req UpdateStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	a, err := SetStatus(id, req.Status)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, a)
+}
+
+// ValidateIBAN checks ?iban and, for IBANs issued by this bank, whether the
+// account exists and can receive money
+func (h *Handler) ValidateIBAN(c *gin.Context) {
+	v := c.Query("iban")
+	if v == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "iban gerekli"})
+		return
+	}
+	info, err := ParseIBAN(v)
+	if err != nil {
+		info.Error = err.Error()
+		c.JSON(http.StatusOK, gin.H{"iban": info})
+		return
+	}
+	resp := gin.H{"iban": info}
+	if info.Internal {
+		a, err := GetByIBAN(info.IBAN)
+		switch {
+		case err == nil:
+			resp["exists"] = true
+			resp["can_receive"] = a.CanCredit()
+		case errors.Is(err, ErrAccountNotFound):
+			resp["exists"] = false
+			resp["can_receive"] = false
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "hesap getirilemedi"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// loadOwned loads the :id account if the caller owns it or is an admin
+func (h *Handler) loadOwned(c *gin.Context) (*Account, bool) {
+	u, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Kimlik doğrulaması gerekli"})
+		return nil, false
+	}
+	id, ok := parseID(c)
+	if !ok {
+		return nil, false
+	}
+	a, err := Get(id)
+	if err == nil && !u.IsAdmin() && a.UserID != u.ID {
+		err = ErrAccountNotFound
+	}
+	if err != nil {
+		respondError(c, err)
+		return nil, false
+	}
+	return a, true
+}
+
+func parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "hesap ID geçersiz"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrAccountNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "hesap bulunamadı"})
+	case errors.Is(err, ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "kullanıcı bulunamadı"})
+	case errors.Is(err, ErrAccountInactive), errors.Is(err, ErrAccountNotEmpty):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,103 @@
+package account
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BankCode is the five-digit code the bank's IBANs are issued under
+const BankCode = "00099"
+
+// CountryCode is the country of the IBANs the bank issues
+const CountryCode = "TR"
+
+// ibanLengths are the ISO 13616 lengths of common countries. Other countries
+// are only checked against the general 15-34 range.
+var ibanLengths = map[string]int{
+	"TR": 26, "DE": 22, "GB": 22, "FR": 27, "NL": 18, "ES": 24, "IT": 27, "BE": 16, "CH": 21, "AT": 20,
+}
+
+// IBANInfo is the result of validating an IBAN
+type IBANInfo struct {
+	IBAN          string `json:"iban"`
+	Valid         bool   `json:"valid"`
+	Error         string `json:"error,omitempty"`
+	Country       string `json:"country,omitempty"`
+	CheckDigits   string `json:"check_digits,omitempty"`
+	BankCode      string `json:"bank_code,omitempty"`
+	AccountNumber string `json:"account_number,omitempty"`
+	// Internal is set for IBANs issued by this bank
+	Internal bool `json:"internal"`
+}
+
+// GenerateIBAN returns the Turkish IBAN of an account: TR, two check digits,
+// the bank code, a zero reserve digit and the account ID as a 16-digit
+// account number.
+func GenerateIBAN(accountID uint) string {
+	bban := fmt.Sprintf("%s0%016d", BankCode, accountID)
+	check := 98 - mod97(bban+CountryCode+"00")
+	return fmt.Sprintf("%s%02d%s", CountryCode, check, bban)
+}
+
+// NormalizeIBAN strips spaces and upper-cases an IBAN
+func NormalizeIBAN(s string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(s), ""))
+}
+
+// ParseIBAN validates the structure and mod-97 check digits of an IBAN
+func ParseIBAN(s string) (IBANInfo, error) {
+	iban := NormalizeIBAN(s)
+	info := IBANInfo{IBAN: iban}
+
+	if len(iban) < 15 || len(iban) > 34 {
+		return info, fmt.Errorf("IBAN must be between 15 and 34 characters")
+	}
+	for i, r := range iban {
+		switch {
+		case i < 2 && (r < 'A' || r > 'Z'):
+			return info, fmt.Errorf("IBAN must start with a country code")
+		case i >= 2 && i < 4 && (r < '0' || r > '9'):
+			return info, fmt.Errorf("IBAN check digits must be numeric")
+		case (r < 'A' || r > 'Z') && (r < '0' || r > '9'):
+			return info, fmt.Errorf("IBAN may only contain letters and digits")
+		}
+	}
+	info.Country, info.CheckDigits = iban[:2], iban[2:4]
+	if n, ok := ibanLengths[info.Country]; ok && len(iban) != n {
+		return info, fmt.Errorf("%s IBANs must be %d characters", info.Country, n)
+	}
+
+	if info.Country == "TR" {
+		bban := iban[4:]
+		for _, r := range bban[:6] {
+			if r < '0' || r > '9' {
+				return info, fmt.Errorf("TR IBAN bank code must be numeric")
+			}
+		}
+		if bban[5] != '0' {
+			return info, fmt.Errorf("TR IBAN reserve digit must be 0")
+		}
+		info.BankCode, info.AccountNumber = bban[:5], bban[6:]
+		info.Internal = info.BankCode == BankCode
+	}
+
+	if mod97(iban[4:]+iban[:4]) != 1 {
+		return info, fmt.Errorf("IBAN check digits are invalid")
+	}
+	info.Valid = true
+	return info, nil
+}
+
+// mod97 computes s mod 97 with letters expanded to two digits (A=10 ... Z=35)
+func mod97(s string) int {
+	rem := 0
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			rem = (rem*10 + int(r-'0')) % 97
+		case r >= 'A' && r <= 'Z':
+			rem = (rem*100 + int(r-'A'+10)) % 97
+		}
+	}
+	return rem
+}
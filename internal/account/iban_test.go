@@ -0,0 +1,56 @@
+package account
+
+import "testing"
+
+func TestGenerateIBANRoundTrip(t *testing.T) {
+	for _, id := range []uint{1, 42, 99999, 1234567890123456} {
+		iban := GenerateIBAN(id)
+		if len(iban) != 26 {
+			t.Fatalf("GenerateIBAN(%d) = %s, want 26 characters", id, iban)
+		}
+		info, err := ParseIBAN(iban)
+		if err != nil {
+			t.Fatalf("ParseIBAN(%s): %v", iban, err)
+		}
+		if !info.Valid || !info.Internal || info.Country != "TR" || info.BankCode != BankCode {
+			t.Fatalf("ParseIBAN(%s) = %+v", iban, info)
+		}
+		if mod97(iban[4:]+iban[:4]) != 1 {
+			t.Fatalf("%s does not satisfy mod 97", iban)
+		}
+	}
+}
+
+func TestParseIBAN(t *testing.T) {
+	tests := []struct {
+		name     string
+		iban     string
+		valid    bool
+		internal bool
+	}{
+		{"TR external", "TR330006100519786457841326", true, false},
+		{"TR spaced lower case", "tr33 0006 1005 1978 6457 8413 26", true, false},
+		{"TR internal", GenerateIBAN(7), true, true},
+		{"DE", "DE89370400440532013000", true, false},
+		{"GB letters in BBAN", "GB82WEST12345698765432", true, false},
+		{"TR bad check digits", "TR340006100519786457841326", false, false},
+		{"TR swapped digits", "TR330006100519786457841362", false, false},
+		{"TR check digits zeroed", "TR000006100519786457841326", false, false},
+		{"TR wrong length", "TR33000610051978645784132", false, false},
+		{"TR reserve digit not 0", "TR330006110519786457841326", false, false},
+		{"non-numeric check digits", "TRAB0006100519786457841326", false, false},
+		{"symbol", "TR33000610051978645784132!", false, false},
+		{"too short", "TR33", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ParseIBAN(tt.iban)
+			if tt.valid != (err == nil) || info.Valid != tt.valid {
+				t.Fatalf("ParseIBAN(%q) = %+v, %v; want valid=%v", tt.iban, info, err, tt.valid)
+			}
+			if info.Internal != tt.internal {
+				t.Fatalf("ParseIBAN(%q).Internal = %v, want %v", tt.iban, info.Internal, tt.internal)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package account
+
+import (
+	"bankapi/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
+	a := router.Group("/api/v1/accounts")
+	i := router.Group("/api/v1/iban")
+
+	// Only use auth middleware if it's provided
+	if authMiddleware != nil {
+		a.Use(authMiddleware)
+		i.Use(authMiddleware)
+	}
+
+	handler := NewHandler()
+
+	a.GET("", handler.ListAccounts)
+	a.POST("", handler.OpenAccount)
+	a.GET("/:id", handler.GetAccount)
+	a.PUT("/:id/status", middleware.RequireRoles("admin"), handler.UpdateStatus)
+
+	i.GET("/validate", handler.ValidateIBAN)
+}
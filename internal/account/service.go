@@ -0,0 +1,191 @@
+package account
+
+import (
+	"bankapi/internal/audit"
+	"bankapi/internal/balance"
+	"bankapi/internal/db"
+	"bankapi/internal/user"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Open opens a new account for userID
+func Open(userID uint, t Type, name string) (*Account, error) {
+	var a *Account
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var n int64
+		if err := tx.Model(&user.User{}).Where("id = ?", userID).Count(&n).Error; err != nil {
+			return fmt.Errorf("failed to load user: %w", err)
+		}
+		if n == 0 {
+			return ErrUserNotFound
+		}
+		var err error
+		a, err = OpenTx(tx, userID, t, name)
+		return err
+	})
+	return a, err
+}
+
+// OpenTx opens an account inside tx. The ID is drawn from the sequence first
+// so the IBAN, which embeds it, is stored with the row.
+func OpenTx(tx *gorm.DB, userID uint, t Type, name string) (*Account, error) {
+	println("🏦 Hesap açılıyor, kullanıcı ID:", userID, "tip:", string(t))
+
+	if t != TypeChecking && t != TypeSavings {
+		return nil, fmt.Errorf("unsupported account type: %s", t)
+	}
+
+	var id uint
+	if err := tx.Raw("SELECT nextval(pg_get_serial_sequence('accounts', 'id'))").Scan(&id).Error; err != nil {
+		return nil, fmt.Errorf("failed to allocate account number: %w", err)
+	}
+
+	a := &Account{
+		ID:     id,
+		UserID: userID,
+		Type:   t,
+		Status: StatusActive,
+		IBAN:   GenerateIBAN(id),
+		Name:   name,
+	}
+	if err := tx.Create(a).Error; err != nil {
+		println("❌ Hesap açılamadı:", err.Error())
+		return nil, fmt.Errorf("failed to open account: %w", err)
+	}
+	if err := audit.LogTx(tx, "account", fmt.Sprintf("%d", a.ID), "open", fmt.Sprintf("user=%d type=%s iban=%s", userID, t, a.IBAN)); err != nil {
+		return nil, err
+	}
+
+	println("✅ Hesap açıldı, IBAN:", a.IBAN)
+	return a, nil
+}
+
+// OpenDefaultTx opens the checking account every new user starts with. It
+// is installed as the user create hook.
+func OpenDefaultTx(tx *gorm.DB, u *user.User) error {
+	_, err := OpenTx(tx, u.ID, TypeChecking, "")
+	return err
+}
+
+func Get(id uint) (*Account, error) {
+	return GetTx(db.DB, id)
+}
+
+// GetTx loads an account inside tx
+func GetTx(tx *gorm.DB, id uint) (*Account, error) {
+	var a Account
+	if err := tx.First(&a, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, fmt.Errorf("failed to load account: %w", err)
+	}
+	return &a, nil
+}
+
+// GetByIBAN loads the account an IBAN was issued for
+func GetByIBAN(iban string) (*Account, error) {
+	var a Account
+	if err := db.DB.Where("iban = ?", NormalizeIBAN(iban)).First(&a).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, fmt.Errorf("failed to load account: %w", err)
+	}
+	return &a, nil
+}
+
+// Resolve finds an account by IBAN or numeric account ID
+func Resolve(ref string) (*Account, error) {
+	ref = strings.TrimSpace(ref)
+	if id, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		if id == 0 {
+			return nil, ErrAccountNotFound
+		}
+		return Get(uint(id))
+	}
+	if _, err := ParseIBAN(ref); err != nil {
+		return nil, err
+	}
+	return GetByIBAN(ref)
+}
+
+// List returns accounts, optionally of one user, oldest first
+func List(userID uint) ([]Account, error) {
+	q := db.DB.Model(&Account{})
+	if userID != 0 {
+		q = q.Where("user_id = ?", userID)
+	}
+	var accounts []Account
+	if err := q.Order("id").Find(&accounts).Error; err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %w", err)
+	}
+	return accounts, nil
+}
+
+// SetStatus freezes, reactivates or closes an account. Only accounts with
+// no money, no credit limit and no active holds can be closed, and a closed
+// account stays closed.
+func SetStatus(id uint, status Status) (*Account, error) {
+	var a Account
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).First(&a, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAccountNotFound
+			}
+			return fmt.Errorf("failed to lock account: %w", err)
+		}
+		if a.Status == StatusClosed {
+			return fmt.Errorf("%w: account is closed", ErrAccountInactive)
+		}
+
+		if status == StatusClosed {
+			// Lock the balances so a payment that is already under way
+			// finishes before they are checked
+			var currencies []string
+			if err := tx.Model(&balance.Balance{}).Where("account_id = ?", id).Pluck("currency", &currencies).Error; err != nil {
+				return fmt.Errorf("failed to load balances: %w", err)
+			}
+			keys := make([]balance.Key, 0, len(currencies))
+			for _, code := range currencies {
+				keys = append(keys, balance.Key{AccountID: id, Currency: code})
+			}
+			balances, err := balance.LockBalances(tx, keys...)
+			if err != nil {
+				return err
+			}
+			for _, b := range balances {
+				held, err := balance.HeldTx(tx, b.Key())
+				if err != nil {
+					return err
+				}
+				if b.AmountCents != 0 || held != 0 {
+					return ErrAccountNotEmpty
+				}
+				if b.CreditLimitCents != 0 {
+					return fmt.Errorf("%w: credit limit of %d %s", ErrAccountNotEmpty, b.CreditLimitCents, b.Currency)
+				}
+			}
+			now := time.Now()
+			a.ClosedAt = &now
+		}
+
+		old := a.Status
+		a.Status = status
+		if err := tx.Save(&a).Error; err != nil {
+			return fmt.Errorf("failed to update account: %w", err)
+		}
+		return audit.LogTx(tx, "account", fmt.Sprintf("%d", a.ID), "status", fmt.Sprintf("%s -> %s", old, status))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
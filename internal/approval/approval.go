@@ -0,0 +1,106 @@
+package approval
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Action string
+type Status string
+
+const (
+	ActionTransfer   Action = "transfer"
+	ActionUserUpdate Action = "user.update"
+	ActionUserDelete Action = "user.delete"
+	ActionRateUpdate Action = "currency.rate_update"
+
+	StatusPending  Status = "pending"
+	StatusApproved Status = "approved"
+	StatusRejected Status = "rejected"
+	StatusFailed   Status = "failed" // approved, but executing the action failed
+)
+
+// DefaultTransferThresholdCents is the transfer amount, in the default
+// currency, above which a second admin has to approve the transfer.
+const DefaultTransferThresholdCents int64 = 10_000_000
+
+var (
+	ErrRequestNotFound = errors.New("approval request not found")
+	ErrNotPending      = errors.New("approval request is not pending")
+	ErrSelfApproval    = errors.New("requester cannot decide on their own request")
+	ErrNoExecutor      = errors.New("no executor registered for action")
+)
+
+// Request is an action parked until a second admin approves or rejects it
+type Request struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	Action       Action     `json:"action" gorm:"size:50;index;not null"`
+	EntityID     string     `json:"entity_id" gorm:"size:64"`
+	Summary      string     `json:"summary" gorm:"size:255"`
+	Payload      string     `json:"payload" gorm:"type:text;not null"`
+	Status       Status     `json:"status" gorm:"size:20;index;not null"`
+	RequestedBy  uint       `json:"requested_by" gorm:"index"`
+	DecidedBy    *uint      `json:"decided_by,omitempty"`
+	DecisionNote string     `json:"decision_note,omitempty" gorm:"size:255"`
+	DecidedAt    *time.Time `json:"decided_at,omitempty"`
+	Result       string     `json:"result,omitempty" gorm:"type:text"`
+	Error        string     `json:"error,omitempty" gorm:"size:255"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (Request) TableName() string { return "approval_requests" }
+
+type DecisionRequest struct {
+	Note string `json:"note" binding:"max=255"`
+}
+
+// Executor performs an approved action from its stored payload and returns
+// whatever should be recorded as the result.
+type Executor func(payload []byte) (interface{}, error)
+
+var (
+	executorsMutex sync.RWMutex
+	executors      = map[Action]Executor{}
+
+	thresholdMutex         sync.RWMutex
+	transferThresholdCents = DefaultTransferThresholdCents
+)
+
+// RegisterExecutor sets the function that runs approved requests of action
+func RegisterExecutor(action Action, exec Executor) {
+	executorsMutex.Lock()
+	defer executorsMutex.Unlock()
+	executors[action] = exec
+}
+
+func executorFor(action Action) (Executor, error) {
+	executorsMutex.RLock()
+	defer executorsMutex.RUnlock()
+	exec, ok := executors[action]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoExecutor, action)
+	}
+	return exec, nil
+}
+
+// SetTransferThreshold changes the amount above which transfers need approval
+func SetTransferThreshold(cents int64) {
+	thresholdMutex.Lock()
+	defer thresholdMutex.Unlock()
+	transferThresholdCents = cents
+}
+
+func TransferThreshold() int64 {
+	thresholdMutex.RLock()
+	defer thresholdMutex.RUnlock()
+	return transferThresholdCents
+}
+
+// Decode unmarshals the request payload into v
+func (r *Request) Decode(v interface{}) error {
+	return json.Unmarshal([]byte(r.Payload), v)
+}
@@ -0,0 +1,99 @@
+package approval
+
+import (
+	"bankapi/internal/middleware"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct{}
+
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// ListRequests returns approval requests, optionally filtered by ?status=
+func (h *Handler) ListRequests(c *gin.Context) {
+	reqs, err := List(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "onay talepleri getirilemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, reqs)
+}
+
+func (h *Handler) GetRequest(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	req, err := Get(id)
+	if err != nil {
+		respondError(c, req, err)
+		return
+	}
+	c.JSON(http.StatusOK, req)
+}
+
+// ApproveRequest approves and executes a pending request
+func (h *Handler) ApproveRequest(c *gin.Context) {
+	h.decide(c, Approve)
+}
+
+// RejectRequest rejects a pending request
+func (h *Handler) RejectRequest(c *gin.Context) {
+	h.decide(c, Reject)
+}
+
+func (h *Handler) decide(c *gin.Context, decide func(id, approverID uint, note string) (*Request, error)) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	approver, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Kimlik doğrulaması gerekli"})
+		return
+	}
+	var body DecisionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Geçersiz veri"})
+			return
+		}
+	}
+
+	req, err := decide(id, approver.ID, body.Note)
+	if err != nil {
+		respondError(c, req, err)
+		return
+	}
+	c.JSON(http.StatusOK, req)
+}
+
+func parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "talep ID geçersiz"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondError(c *gin.Context, req *Request, err error) {
+	switch {
+	case errors.Is(err, ErrRequestNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "onay talebi bulunamadı"})
+	case errors.Is(err, ErrSelfApproval):
+		c.JSON(http.StatusForbidden, gin.H{"error": "kendi talebinizi onaylayamaz veya reddedemezsiniz"})
+	case errors.Is(err, ErrNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": "onay talebi beklemede değil", "approval": req})
+	case req != nil && req.Status == StatusFailed:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "onaylanan işlem gerçekleştirilemedi", "approval": req})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "onay talebi işlenemedi"})
+	}
+}
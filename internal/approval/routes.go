@@ -0,0 +1,25 @@
+package approval
+
+import (
+	"bankapi/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
+	registerUserApprovals()
+
+	a := router.Group("/api/v1/approvals")
+
+	// Only use auth middleware if it's provided
+	if authMiddleware != nil {
+		a.Use(authMiddleware, middleware.RequireRoles("admin"))
+	}
+
+	handler := NewHandler()
+
+	a.GET("", handler.ListRequests)
+	a.GET("/:id", handler.GetRequest)
+	a.POST("/:id/approve", handler.ApproveRequest)
+	a.POST("/:id/reject", handler.RejectRequest)
+}
@@ -0,0 +1,156 @@
+package approval
+
+import (
+	"bankapi/internal/audit"
+	"bankapi/internal/db"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Submit parks an action for approval. The payload is stored as JSON and
+// handed to the action's executor once a different admin approves it.
+func Submit(action Action, entityID string, payload interface{}, requestedBy uint, summary string) (*Request, error) {
+	println("📨 Onay talebi oluşturuluyor:", string(action), "talep eden:", requestedBy)
+
+	if _, err := executorFor(action); err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode approval payload: %w", err)
+	}
+
+	req := &Request{
+		Action:      action,
+		EntityID:    entityID,
+		Summary:     summary,
+		Payload:     string(raw),
+		Status:      StatusPending,
+		RequestedBy: requestedBy,
+	}
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(req).Error; err != nil {
+			return fmt.Errorf("failed to create approval request: %w", err)
+		}
+		return audit.LogTx(tx, "approval", fmt.Sprintf("%d", req.ID), "request", fmt.Sprintf("action=%s entity=%s requester=%d %s", action, entityID, requestedBy, summary))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	println("✅ Onay talebi oluşturuldu, ID:", req.ID)
+	return req, nil
+}
+
+// Approve records the approver's decision and then executes the action. If
+// the executor fails the request ends up failed with the error recorded.
+func Approve(id, approverID uint, note string) (*Request, error) {
+	println("👍 Onay talebi onaylanıyor, ID:", id, "onaylayan:", approverID)
+
+	req, err := decide(id, approverID, StatusApproved, note)
+	if err != nil {
+		return req, err
+	}
+
+	exec, err := executorFor(req.Action)
+	if err == nil {
+		var result interface{}
+		result, err = exec([]byte(req.Payload))
+		if err == nil {
+			if raw, mErr := json.Marshal(result); mErr == nil {
+				req.Result = string(raw)
+			}
+		}
+	}
+	if err != nil {
+		println("❌ Onaylanan işlem çalıştırılamadı:", err.Error())
+		req.Status = StatusFailed
+		req.Error = err.Error()
+	}
+
+	if saveErr := db.DB.Save(req).Error; saveErr != nil {
+		println("⚠️ Onay talebi sonucu kaydedilemedi:", saveErr.Error())
+	}
+	if err != nil {
+		audit.Log("approval", fmt.Sprintf("%d", req.ID), "execution_failed", err.Error())
+		return req, err
+	}
+	audit.Log("approval", fmt.Sprintf("%d", req.ID), "executed", string(req.Action))
+	return req, nil
+}
+
+// Reject records the decision without executing anything
+func Reject(id, approverID uint, note string) (*Request, error) {
+	println("👎 Onay talebi reddediliyor, ID:", id, "reddeden:", approverID)
+	return decide(id, approverID, StatusRejected, note)
+}
+
+// decide moves a pending request to status. The row is locked so that two
+// admins deciding at once cannot both win, and the requester is refused.
+func decide(id, approverID uint, status Status, note string) (*Request, error) {
+	var req Request
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).First(&req, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRequestNotFound
+			}
+			return fmt.Errorf("failed to load approval request: %w", err)
+		}
+		if req.Status != StatusPending {
+			return ErrNotPending
+		}
+		if req.RequestedBy != 0 && req.RequestedBy == approverID {
+			return ErrSelfApproval
+		}
+
+		now := time.Now()
+		req.Status = status
+		req.DecidedBy = &approverID
+		req.DecidedAt = &now
+		req.DecisionNote = note
+		if err := tx.Save(&req).Error; err != nil {
+			return fmt.Errorf("failed to update approval request: %w", err)
+		}
+
+		action := "approve"
+		if status == StatusRejected {
+			action = "reject"
+		}
+		return audit.LogTx(tx, "approval", fmt.Sprintf("%d", req.ID), action, fmt.Sprintf("action=%s requester=%d approver=%d note=%s", req.Action, req.RequestedBy, approverID, note))
+	})
+	if errors.Is(err, ErrSelfApproval) {
+		audit.Log("approval", fmt.Sprintf("%d", id), "self_approval_denied", fmt.Sprintf("user=%d", approverID))
+	}
+	if err != nil {
+		return &req, err
+	}
+	return &req, nil
+}
+
+func List(status string) ([]Request, error) {
+	q := db.DB.Order("created_at DESC, id DESC")
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	var reqs []Request
+	if err := q.Find(&reqs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list approval requests: %w", err)
+	}
+	return reqs, nil
+}
+
+func Get(id uint) (*Request, error) {
+	var req Request
+	if err := db.DB.First(&req, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to load approval request: %w", err)
+	}
+	return &req, nil
+}
@@ -0,0 +1,34 @@
+package approval
+
+import (
+	"bankapi/internal/user"
+	"encoding/json"
+)
+
+// registerUserApprovals wires role changes and deletes in the user package
+// through maker-checker approval. The user package exposes a hook instead of
+// importing this package because middleware already depends on it.
+func registerUserApprovals() {
+	RegisterExecutor(ActionUserUpdate, func(payload []byte) (interface{}, error) {
+		var p user.UpdateUserPayload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return nil, err
+		}
+		u, err := user.ApplyUpdate(p.UserID, p.UpdateUserRequest)
+		if err != nil {
+			return nil, err
+		}
+		return u.ToResponse(), nil
+	})
+	RegisterExecutor(ActionUserDelete, func(payload []byte) (interface{}, error) {
+		var p user.DeleteUserPayload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return nil, err
+		}
+		return p, user.Delete(p.UserID)
+	})
+
+	user.SetApprovalGate(func(action, entityID string, payload interface{}, requestedBy uint, summary string) (interface{}, error) {
+		return Submit(Action(action), entityID, payload, requestedBy, summary)
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"bankapi/internal/db"
 	"fmt"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type AuditLog struct {
@@ -55,6 +57,32 @@ func Log(entityType, entityID, action, details string) {
 	}
 }
 
+// LogTx writes an audit entry inside the given database transaction so that it
+// commits or rolls back together with the change it describes.
+func LogTx(tx *gorm.DB, entityType, entityID, action, details string) error {
+	println("📋 AUDIT LOG (tx):", entityType, entityID, action, details)
+
+	if entityType == "" || entityID == "" || action == "" {
+		println("⚠️ Audit log alanları eksik")
+		return fmt.Errorf("audit log requires entity type, entity id and action")
+	}
+
+	auditLog := AuditLog{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Details:    details,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := tx.Create(&auditLog).Error; err != nil {
+		println("❌ Audit log transaction içinde kaydedilemedi:", err.Error())
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	return nil
+}
+
 // GetAuditLogs retrieves audit logs for a specific entity
 func GetAuditLogs(entityType, entityID string) ([]AuditLog, error) {
 	println("🔍 Audit loglar aranıyor, entity:", entityType, "ID:", entityID)
@@ -0,0 +1,274 @@
+package balance
+
+import (
+	"bankapi/internal/db"
+	"bankapi/internal/events"
+	"bankapi/internal/logger"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Events of the account aggregate. Balance and BalanceHistory are
+// projections of them.
+const (
+	EventCredited       = "account.credited"
+	EventDebited        = "account.debited"
+	EventCreditLimitSet = "account.credit_limit_set"
+
+	aggregatePrefix = "account-"
+
+	// AccountSchemaVersion identifies how apply folds events. Bump it when
+	// that changes; snapshots taken with an older version are then ignored.
+	AccountSchemaVersion = 1
+	// DefaultSnapshotEvery is how many events pass between two snapshots
+	DefaultSnapshotEvery = 100
+)
+
+var snapshotEvery atomic.Int64
+
+func init() {
+	snapshotEvery.Store(DefaultSnapshotEvery)
+}
+
+// SetSnapshotEvery sets how many events of an account pass between two
+// automatic snapshots; zero turns them off
+func SetSnapshotEvery(n int64) {
+	snapshotEvery.Store(n)
+}
+
+// AggregateID is the event stream of an account
+func AggregateID(accountID uint) string {
+	return aggregatePrefix + strconv.FormatUint(uint64(accountID), 10)
+}
+
+// parseAggregateID returns the account of an account stream
+func parseAggregateID(id string) (uint, bool) {
+	if !strings.HasPrefix(id, aggregatePrefix) {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(strings.TrimPrefix(id, aggregatePrefix), 10, 64)
+	if err != nil || n == 0 {
+		return 0, false
+	}
+	return uint(n), true
+}
+
+// AccountAggregate is the state of an account's balances as derived from its
+// events. Version is the last event applied, UpdatedAt its time and Sequence
+// the balance history sequence it reached.
+type AccountAggregate struct {
+	AccountID uint                `json:"account_id"`
+	Version   int                 `json:"version"`
+	Sequence  int64               `json:"sequence"`
+	UpdatedAt time.Time           `json:"updated_at"`
+	Balances  map[string]*Balance `json:"balances"`
+}
+
+// NewAccountAggregate returns an account with no events applied
+func NewAccountAggregate(accountID uint) *AccountAggregate {
+	return &AccountAggregate{AccountID: accountID, Balances: map[string]*Balance{}}
+}
+
+// Apply folds one event into the aggregate
+func (a *AccountAggregate) Apply(e events.Event) error {
+	_, err := a.apply(e)
+	return err
+}
+
+// apply folds e into the aggregate and returns the balance history entry a
+// money movement projects to, nil for other events
+func (a *AccountAggregate) apply(e events.Event) (*BalanceHistory, error) {
+	if e.Version != a.Version+1 {
+		return nil, fmt.Errorf("event %s of account %d has version %d, want %d", e.ID, a.AccountID, e.Version, a.Version+1)
+	}
+	cur, _ := e.Data["currency"].(string)
+	if cur == "" {
+		return nil, fmt.Errorf("event %s has no currency", e.ID)
+	}
+	b, ok := a.Balances[cur]
+	if !ok {
+		b = &Balance{AccountID: a.AccountID, Currency: cur}
+		a.Balances[cur] = b
+	}
+
+	var h *BalanceHistory
+	switch e.Type {
+	case EventCredited, EventDebited:
+		amount, err := intField(e.Data, "amount_cents")
+		if err != nil {
+			return nil, fmt.Errorf("event %s: %w", e.ID, err)
+		}
+		delta := amount
+		if e.Type == EventDebited {
+			delta = -amount
+		}
+		a.Sequence++
+		b.AmountCents += delta
+		h = &BalanceHistory{
+			AccountID:     a.AccountID,
+			Sequence:      a.Sequence,
+			Currency:      cur,
+			EntryType:     stringField(e.Data, "entry_type"),
+			DeltaCents:    delta,
+			PreviousCents: b.AmountCents - delta,
+			AmountCents:   b.AmountCents,
+			CreatedAt:     e.Timestamp,
+		}
+		if e.Data["transaction_id"] != nil {
+			id, err := intField(e.Data, "transaction_id")
+			if err != nil {
+				return nil, fmt.Errorf("event %s: %w", e.ID, err)
+			}
+			txID := uint(id)
+			h.TransactionID = &txID
+		}
+	case EventCreditLimitSet:
+		limit, err := intField(e.Data, "limit_cents")
+		if err != nil {
+			return nil, fmt.Errorf("event %s: %w", e.ID, err)
+		}
+		rate, err := intField(e.Data, "overdraft_rate_bps")
+		if err != nil {
+			return nil, fmt.Errorf("event %s: %w", e.ID, err)
+		}
+		b.CreditLimitCents = limit
+		b.OverdraftRateBps = rate
+	default:
+		return nil, fmt.Errorf("unknown account event type %s", e.Type)
+	}
+
+	b.LastUpdated = e.Timestamp
+	a.Version = e.Version
+	a.UpdatedAt = e.Timestamp
+	return h, nil
+}
+
+// LoadAccount returns the account from its latest snapshot plus the events
+// after it. With until set only events up to that time are applied, giving
+// the account as it was then.
+func LoadAccount(accountID uint, until *time.Time) (*AccountAggregate, error) {
+	return loadAccount(events.NewPostgresEventStore(db.DB), accountID, until)
+}
+
+func loadAccount(store *events.PostgresEventStore, accountID uint, until *time.Time) (*AccountAggregate, error) {
+	id := AggregateID(accountID)
+	a := NewAccountAggregate(accountID)
+	if _, err := store.LatestSnapshot(id, AccountSchemaVersion, until, a); err != nil {
+		return nil, err
+	}
+	if a.Balances == nil {
+		a.Balances = map[string]*Balance{}
+	}
+
+	for {
+		page, err := store.GetEventsFrom(id, a.Version, events.MaxPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range page {
+			if until != nil && e.Timestamp.After(*until) {
+				return a, nil
+			}
+			if err := a.Apply(e); err != nil {
+				return nil, err
+			}
+		}
+		if len(page) < events.MaxPageSize {
+			return a, nil
+		}
+	}
+}
+
+// SnapshotAccount stores the account's current state as a snapshot
+func SnapshotAccount(accountID uint) (*AccountAggregate, error) {
+	store := events.NewPostgresEventStore(db.DB)
+	a, err := loadAccount(store, accountID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if a.Version == 0 {
+		return a, nil
+	}
+	if err := store.SaveSnapshot(AggregateID(accountID), a.Version, AccountSchemaVersion, a.UpdatedAt, a); err != nil {
+		return nil, err
+	}
+	println("📸 Hesap görüntüsü alındı, hesap ID:", accountID, "versiyon:", a.Version)
+	return a, nil
+}
+
+// InvalidateSnapshots deletes the snapshots of an account, or of every
+// account when accountID is zero
+func InvalidateSnapshots(accountID uint) (int64, error) {
+	id := ""
+	if accountID != 0 {
+		id = AggregateID(accountID)
+	}
+	n, err := events.NewPostgresEventStore(db.DB).DeleteSnapshots(id)
+	if err != nil {
+		return 0, err
+	}
+	logger.Info("Account snapshots invalidated", map[string]interface{}{
+		"account_id": accountID,
+		"deleted":    n,
+	})
+	return n, nil
+}
+
+// appendEventTx records an event of the account inside tx and snapshots the
+// account every SetSnapshotEvery events. The caller holds the account's
+// sequence lock through LockBalances, so the version read here cannot move
+// before the append.
+func appendEventTx(tx *gorm.DB, accountID uint, eventType string, at time.Time, data map[string]interface{}) error {
+	store := events.NewPostgresEventStore(tx)
+	id := AggregateID(accountID)
+	version, err := store.Version(id)
+	if err != nil {
+		return err
+	}
+	e := events.NewEvent(eventType, id, data)
+	e.Version = version + 1
+	e.Timestamp = at
+	if err := store.AppendTx(tx, id, e); err != nil {
+		return err
+	}
+
+	every := snapshotEvery.Load()
+	if every <= 0 || int64(e.Version)%every != 0 {
+		return nil
+	}
+	a, err := loadAccount(store, accountID, nil)
+	if err != nil {
+		return err
+	}
+	return store.SaveSnapshot(id, a.Version, AccountSchemaVersion, a.UpdatedAt, a)
+}
+
+// intField reads an integer from event data, which holds json.Number once
+// read back from the store
+func intField(data map[string]interface{}, key string) (int64, error) {
+	switch v := data[key].(type) {
+	case json.Number:
+		return v.Int64()
+	case float64:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case uint:
+		return int64(v), nil
+	default:
+		return 0, fmt.Errorf("field %s is not an integer", key)
+	}
+}
+
+func stringField(data map[string]interface{}, key string) string {
+	s, _ := data[key].(string)
+	return s
+}
@@ -1,22 +1,55 @@
 package balance
 
 import (
+	"bankapi/internal/currency"
 	"fmt"
 	"sync"
 	"time"
 )
 
+// Balance is one account's balance in one ISO currency. An arranged overdraft
+// lets AmountCents go negative down to -CreditLimitCents; a zero
+// OverdraftRateBps means the default overdraft rate applies.
 type Balance struct {
-	UserID      uint      `json:"user_id" gorm:"primaryKey"`
-	AmountCents int64     `json:"amount_cents" gorm:"not null;default:0"`
-	LastUpdated time.Time `json:"last_updated_at"`
+	AccountID        uint      `json:"account_id" gorm:"primaryKey"`
+	Currency         string    `json:"currency" gorm:"primaryKey;size:3"`
+	AmountCents      int64     `json:"amount_cents" gorm:"not null;default:0"`
+	CreditLimitCents int64     `json:"credit_limit_cents" gorm:"not null;default:0"`
+	OverdraftRateBps int64     `json:"overdraft_rate_bps" gorm:"not null;default:0"`
+	LastUpdated      time.Time `json:"last_updated_at"`
 }
 
+// Key identifies a balance row
+type Key struct {
+	AccountID uint
+	Currency  string
+}
+
+func (b Balance) Key() Key {
+	return Key{AccountID: b.AccountID, Currency: b.Currency}
+}
+
+// BalanceHistory is one change of a balance. Sequence numbers the changes of
+// an account across all its currencies without gaps; AmountCents is the
+// balance after the change and PreviousCents the balance before it.
 type BalanceHistory struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	UserID      uint      `json:"user_id" gorm:"index"`
-	AmountCents int64     `json:"amount_cents"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	AccountID     uint      `json:"account_id" gorm:"index;uniqueIndex:idx_balance_history_seq"`
+	Sequence      int64     `json:"sequence" gorm:"not null;uniqueIndex:idx_balance_history_seq"`
+	Currency      string    `json:"currency" gorm:"size:3;index"`
+	TransactionID *uint     `json:"transaction_id,omitempty" gorm:"index"`
+	EntryType     string    `json:"entry_type" gorm:"size:30"`
+	DeltaCents    int64     `json:"delta_cents"`
+	PreviousCents int64     `json:"previous_cents"`
+	AmountCents   int64     `json:"amount_cents"`
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+}
+
+// Entry describes what caused a balance change. It is copied onto the
+// history row; Type is usually the type of the transaction.
+type Entry struct {
+	TransactionID *uint
+	Type          string
 }
 
 // Thread-safe balance operations
@@ -26,12 +59,13 @@ type ThreadSafeBalance struct {
 }
 
 // NewThreadSafeBalance creates a new thread-safe balance
-func NewThreadSafeBalance(userID uint) *ThreadSafeBalance {
-	println("💰 Thread-safe balance oluşturuluyor, kullanıcı ID:", userID)
+func NewThreadSafeBalance(accountID uint) *ThreadSafeBalance {
+	println("💰 Thread-safe balance oluşturuluyor, hesap ID:", accountID)
 
 	balance := &ThreadSafeBalance{
 		Balance: Balance{
-			UserID:      userID,
+			AccountID:   accountID,
+			Currency:    currency.DefaultCurrency,
 			AmountCents: 0,
 			LastUpdated: time.Now(),
 		},
@@ -92,7 +126,7 @@ func (b *ThreadSafeBalance) TransferAmount(target *ThreadSafeBalance, amountCent
 	}
 
 	// Lock both balances to prevent deadlock
-	if b.UserID < target.UserID {
+	if b.AccountID < target.AccountID {
 		b.mutex.Lock()
 		target.mutex.Lock()
 	} else {
@@ -0,0 +1,79 @@
+package balance
+
+import (
+	"bankapi/internal/audit"
+	"bankapi/internal/db"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SetCreditLimitRequest arranges an overdraft on one balance. A zero limit
+// removes the overdraft; OverdraftRateBps is the annual rate charged on the
+// negative balance, zero meaning the default rate.
+type SetCreditLimitRequest struct {
+	LimitCents       int64 `json:"limit_cents" binding:"gte=0"`
+	OverdraftRateBps int64 `json:"overdraft_rate_bps" binding:"gte=0,lte=100000"`
+}
+
+// SetCreditLimit sets the credit limit and overdraft rate of a balance,
+// creating the balance if needed. Lowering the limit below what is already
+// drawn is allowed; further debits are refused until the balance recovers.
+func SetCreditLimit(accountID uint, currency string, limit, rateBps int64) (*Balance, error) {
+	println("💳 Kredi limiti ayarlanıyor, hesap ID:", accountID, "limit:", limit, currency)
+
+	if limit < 0 || rateBps < 0 {
+		return nil, fmt.Errorf("credit limit and overdraft rate must not be negative")
+	}
+
+	var b *Balance
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		key := Key{AccountID: accountID, Currency: currency}
+		locked, err := LockBalances(tx, key)
+		if err != nil {
+			return err
+		}
+		b = locked[key]
+
+		if err := appendEventTx(tx, accountID, EventCreditLimitSet, time.Now(), map[string]interface{}{
+			"currency":           currency,
+			"limit_cents":        limit,
+			"overdraft_rate_bps": rateBps,
+		}); err != nil {
+			return err
+		}
+
+		old := b.CreditLimitCents
+		if err := tx.Model(b).Updates(map[string]interface{}{
+			"credit_limit_cents": limit,
+			"overdraft_rate_bps": rateBps,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update credit limit: %w", err)
+		}
+		b.CreditLimitCents = limit
+		b.OverdraftRateBps = rateBps
+
+		return audit.LogTx(tx, "balance", fmt.Sprintf("%d", accountID), "credit_limit", fmt.Sprintf("%s limit %d -> %d rate=%d", currency, old, limit, rateBps))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	println("✅ Kredi limiti ayarlandı")
+	return b, nil
+}
+
+// ListCreditLimits returns balances with an arranged overdraft, optionally
+// for one account
+func ListCreditLimits(accountID uint) ([]Balance, error) {
+	q := db.DB.Where("credit_limit_cents > 0")
+	if accountID != 0 {
+		q = q.Where("account_id = ?", accountID)
+	}
+	var balances []Balance
+	if err := q.Order("account_id, currency").Find(&balances).Error; err != nil {
+		return nil, fmt.Errorf("failed to load credit limits: %w", err)
+	}
+	return balances, nil
+}
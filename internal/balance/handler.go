@@ -1,10 +1,14 @@
 package balance
 
 import (
-	"bankapi/internal/db"
+	"bankapi/internal/currency"
+	"bankapi/internal/middleware"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -14,23 +18,59 @@ func RegisterRoutes(router *gin.Engine, middlewares ...gin.HandlerFunc) {
 		r.GET("/current", handleCurrent)
 		r.GET("/historical", handleHistorical)
 		r.GET("/at-time", handleAtTime)
+		r.GET("/series", handleSeries)
 	}
 }
 
+// RegisterCreditLimitRoutes registers the admin endpoints for arranged
+// overdrafts
+func RegisterCreditLimitRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
+	r := router.Group("/api/v1/credit-limits")
+
+	// Only use auth middleware if it's provided
+	if authMiddleware != nil {
+		r.Use(authMiddleware, middleware.RequireRoles("admin"))
+	}
+
+	r.GET("", handleListCreditLimits)
+	r.PUT("/:account_id/:currency", handleSetCreditLimit)
+}
+
+// RegisterProjectionRoutes registers the admin endpoints that maintain the
+// balance projections
+func RegisterProjectionRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
+	r := router.Group("/api/v1/admin/projections")
+
+	// Only use auth middleware if it's provided
+	if authMiddleware != nil {
+		r.Use(authMiddleware, middleware.RequireRoles("admin"))
+	}
+
+	r.POST("/balances/rebuild", handleRebuildProjections)
+	r.GET("/accounts/:account_id", handleGetAggregate)
+	r.POST("/accounts/:account_id/snapshot", handleSnapshotAggregate)
+	r.DELETE("/snapshots", handleInvalidateSnapshots)
+}
+
 func handleCurrent(c *gin.Context) {
-	userIDParam := c.Query("user_id")
-	if userIDParam == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id gerekli"})
+	accountIDParam := c.Query("account_id")
+	if accountIDParam == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account_id gerekli"})
 		return
 	}
 	// basitçe parse et
-	var userID uint
-	_, err := fmt.Sscanf(userIDParam, "%d", &userID)
-	if err != nil || userID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id geçersiz"})
+	var accountID uint
+	_, err := fmt.Sscanf(accountIDParam, "%d", &accountID)
+	if err != nil || accountID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account_id geçersiz"})
+		return
+	}
+	code := c.DefaultQuery("currency", currency.DefaultCurrency)
+	if !currency.Default().IsSupported(code) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "para birimi desteklenmiyor"})
 		return
 	}
-	b, err := GetOrCreateBalance(userID)
+	b, err := GetBalanceView(accountID, code)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "bakiye getirilemedi"})
 		return
@@ -38,37 +78,82 @@ func handleCurrent(c *gin.Context) {
 	c.JSON(http.StatusOK, b)
 }
 
+// handleHistorical returns the account's activity feed newest first. It
+// filters by ?currency, ?type and the ?from/?to range (RFC3339) and pages
+// with ?limit and the ?cursor returned as next_cursor.
 func handleHistorical(c *gin.Context) {
-	userIDParam := c.Query("user_id")
-	if userIDParam == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id gerekli"})
+	accountIDParam := c.Query("account_id")
+	if accountIDParam == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account_id gerekli"})
 		return
 	}
-	var userID uint
-	_, err := fmt.Sscanf(userIDParam, "%d", &userID)
-	if err != nil || userID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id geçersiz"})
+	var accountID uint
+	_, err := fmt.Sscanf(accountIDParam, "%d", &accountID)
+	if err != nil || accountID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account_id geçersiz"})
 		return
 	}
-	var hist []BalanceHistory
-	if err := db.DB.Where("user_id = ?", userID).Order("id DESC").Limit(200).Find(&hist).Error; err != nil {
+
+	f := HistoryFilter{AccountID: accountID, Currency: strings.ToUpper(c.Query("currency")), EntryType: c.Query("type")}
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from tarih formatı RFC3339 olmalı"})
+			return
+		}
+		f.From = &t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to tarih formatı RFC3339 olmalı"})
+			return
+		}
+		f.To = &t
+	}
+	if v := c.Query("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit geçersiz"})
+			return
+		}
+	}
+	if v := c.Query("cursor"); v != "" {
+		if f.BeforeSequence, err = strconv.ParseInt(v, 10, 64); err != nil || f.BeforeSequence <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cursor geçersiz"})
+			return
+		}
+	}
+
+	hist, next, err := ListHistory(f)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "geçmiş getirilemedi"})
 		return
 	}
-	c.JSON(http.StatusOK, hist)
+
+	resp := gin.H{"entries": hist, "next_cursor": nil, "next": nil}
+	if next != 0 {
+		cursor := strconv.FormatInt(next, 10)
+		q := c.Request.URL.Query()
+		q.Set("cursor", cursor)
+		resp["next_cursor"] = cursor
+		resp["next"] = c.Request.URL.Path + "?" + q.Encode()
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
+// handleAtTime returns the balance at ?at by replaying the account's events
+// up to that time
 func handleAtTime(c *gin.Context) {
-	userIDParam := c.Query("user_id")
+	accountIDParam := c.Query("account_id")
 	at := c.Query("at")
-	if userIDParam == "" || at == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id ve at gerekli"})
+	if accountIDParam == "" || at == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account_id ve at gerekli"})
 		return
 	}
-	var userID uint
-	_, err := fmt.Sscanf(userIDParam, "%d", &userID)
-	if err != nil || userID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id geçersiz"})
+	var accountID uint
+	_, err := fmt.Sscanf(accountIDParam, "%d", &accountID)
+	if err != nil || accountID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account_id geçersiz"})
 		return
 	}
 	t, err := time.Parse(time.RFC3339, at)
@@ -76,10 +161,191 @@ func handleAtTime(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "tarih formatı RFC3339 olmalı"})
 		return
 	}
-	var hist BalanceHistory
-	if err := db.DB.Where("user_id = ? AND created_at <= ?", userID, t).Order("created_at DESC").First(&hist).Error; err != nil {
+	code := strings.ToUpper(c.DefaultQuery("currency", currency.DefaultCurrency))
+	a, err := LoadAccount(accountID, &t)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "bakiye hesaplanamadı"})
+		return
+	}
+	b, ok := a.Balances[code]
+	if !ok {
 		c.JSON(http.StatusNotFound, gin.H{"error": "kayıt bulunamadı"})
 		return
 	}
-	c.JSON(http.StatusOK, hist)
+	c.JSON(http.StatusOK, gin.H{
+		"account_id":         accountID,
+		"currency":           code,
+		"at":                 t,
+		"amount_cents":       b.AmountCents,
+		"credit_limit_cents": b.CreditLimitCents,
+		"version":            a.Version,
+	})
+}
+
+// handleSeries returns the closing balances between ?from and ?to
+// (YYYY-MM-DD, the last 30 days by default) per ?interval=day|week|month
+func handleSeries(c *gin.Context) {
+	var accountID uint
+	if _, err := fmt.Sscanf(c.Query("account_id"), "%d", &accountID); err != nil || accountID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account_id geçersiz"})
+		return
+	}
+	code := strings.ToUpper(c.DefaultQuery("currency", currency.DefaultCurrency))
+	if !currency.Default().IsSupported(code) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "para birimi desteklenmiyor"})
+		return
+	}
+	interval := Interval(c.DefaultQuery("interval", string(IntervalDay)))
+	if interval != IntervalDay && interval != IntervalWeek && interval != IntervalMonth {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "interval day, week veya month olmalı"})
+		return
+	}
+
+	to := startOfDay(time.Now())
+	if v := c.Query("to"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to tarih formatı YYYY-MM-DD olmalı"})
+			return
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -29)
+	if v := c.Query("from"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from tarih formatı YYYY-MM-DD olmalı"})
+			return
+		}
+		from = t
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to, from tarihinden önce olamaz"})
+		return
+	}
+
+	points, err := Series(accountID, code, from, to, interval)
+	if err != nil {
+		if errors.Is(err, ErrSeriesTooLong) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tarih aralığı çok uzun"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "bakiye serisi getirilemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"account_id": accountID,
+		"currency":   code,
+		"interval":   interval,
+		"from":       from.Format("2006-01-02"),
+		"to":         to.Format("2006-01-02"),
+		"points":     points,
+	})
+}
+
+func handleRebuildProjections(c *gin.Context) {
+	result, err := RebuildProjections()
+	if err != nil {
+		if errors.Is(err, ErrRebuildInProgress) {
+			c.JSON(http.StatusConflict, gin.H{"error": "projeksiyonlar zaten yeniden oluşturuluyor"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "projeksiyonlar yeniden oluşturulamadı"})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func handleGetAggregate(c *gin.Context) {
+	var accountID uint
+	if _, err := fmt.Sscanf(c.Param("account_id"), "%d", &accountID); err != nil || accountID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account_id geçersiz"})
+		return
+	}
+	a, err := LoadAccount(accountID, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "hesap yüklenemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, a)
+}
+
+func handleSnapshotAggregate(c *gin.Context) {
+	var accountID uint
+	if _, err := fmt.Sscanf(c.Param("account_id"), "%d", &accountID); err != nil || accountID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account_id geçersiz"})
+		return
+	}
+	a, err := SnapshotAccount(accountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "görüntü alınamadı"})
+		return
+	}
+	if a.Version == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "hesabın olayı yok"})
+		return
+	}
+	c.JSON(http.StatusCreated, a)
+}
+
+// handleInvalidateSnapshots deletes the snapshots of ?account_id, or of every
+// account without it
+func handleInvalidateSnapshots(c *gin.Context) {
+	var accountID uint
+	if v := c.Query("account_id"); v != "" {
+		if _, err := fmt.Sscanf(v, "%d", &accountID); err != nil || accountID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "account_id geçersiz"})
+			return
+		}
+	}
+	n, err := InvalidateSnapshots(accountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "görüntüler silinemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": n})
+}
+
+func handleListCreditLimits(c *gin.Context) {
+	var accountID uint
+	if v := c.Query("account_id"); v != "" {
+		if _, err := fmt.Sscanf(v, "%d", &accountID); err != nil || accountID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "account_id geçersiz"})
+			return
+		}
+	}
+	balances, err := ListCreditLimits(accountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "kredi limitleri getirilemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, balances)
+}
+
+func handleSetCreditLimit(c *gin.Context) {
+	var accountID uint
+	if _, err := fmt.Sscanf(c.Param("account_id"), "%d", &accountID); err != nil || accountID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account_id geçersiz"})
+		return
+	}
+	code := strings.ToUpper(c.Param("currency"))
+	if !currency.Default().IsSupported(code) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "para birimi desteklenmiyor"})
+		return
+	}
+	var req SetCreditLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := SetCreditLimit(accountID, code, req.LimitCents, req.OverdraftRateBps); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	view, err := GetBalanceView(accountID, code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "bakiye getirilemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, view)
 }
@@ -0,0 +1,65 @@
+package balance
+
+import (
+	"bankapi/internal/db"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 200
+)
+
+// HistoryFilter selects an account's activity. BeforeSequence pages backwards
+// from the newest entry.
+type HistoryFilter struct {
+	AccountID      uint
+	Currency       string
+	EntryType      string
+	From           *time.Time
+	To             *time.Time
+	BeforeSequence int64
+	Limit          int
+}
+
+// ListHistory returns the account's balance changes newest first and the
+// sequence to continue from, or zero when there are no more entries.
+func ListHistory(f HistoryFilter) ([]BalanceHistory, int64, error) {
+	if f.Limit <= 0 {
+		f.Limit = defaultHistoryLimit
+	}
+	if f.Limit > maxHistoryLimit {
+		f.Limit = maxHistoryLimit
+	}
+
+	query := db.DB.Where("account_id = ?", f.AccountID)
+	if f.Currency != "" {
+		query = query.Where("currency = ?", f.Currency)
+	}
+	if f.EntryType != "" {
+		query = query.Where("entry_type = ?", f.EntryType)
+	}
+	if f.From != nil {
+		query = query.Where("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("created_at < ?", *f.To)
+	}
+	if f.BeforeSequence > 0 {
+		query = query.Where("sequence < ?", f.BeforeSequence)
+	}
+
+	// One extra row tells whether another page exists
+	var hist []BalanceHistory
+	if err := query.Order("sequence DESC").Limit(f.Limit + 1).Find(&hist).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to load balance history: %w", err)
+	}
+
+	var next int64
+	if len(hist) > f.Limit {
+		hist = hist[:f.Limit]
+		next = hist[len(hist)-1].Sequence
+	}
+	return hist, next, nil
+}
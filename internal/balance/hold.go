@@ -0,0 +1,219 @@
+package balance
+
+import (
+	"bankapi/internal/audit"
+	"bankapi/internal/db"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type HoldStatus string
+
+const (
+	HoldStatusActive   HoldStatus = "active"
+	HoldStatusCaptured HoldStatus = "captured"
+	HoldStatusVoided   HoldStatus = "voided"
+	HoldStatusExpired  HoldStatus = "expired"
+)
+
+// DefaultHoldTTL is used when a hold is placed without an expiry
+const DefaultHoldTTL = 7 * 24 * time.Hour
+
+// Hold reserves funds on a balance until it is captured, voided or expires
+type Hold struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	AccountID     uint       `json:"account_id" gorm:"index;not null"`
+	Currency      string     `json:"currency" gorm:"size:3;not null"`
+	AmountCents   int64      `json:"amount_cents" gorm:"not null;check:amount_cents>0"`
+	CapturedCents int64      `json:"captured_cents" gorm:"not null;default:0"`
+	Status        HoldStatus `json:"status" gorm:"size:20;not null;index"`
+	Reference     string     `json:"reference" gorm:"size:100"`
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"index"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// IsActive reports whether the hold still reserves funds
+func (h Hold) IsActive() bool {
+	return h.Status == HoldStatusActive && time.Now().Before(h.ExpiresAt)
+}
+
+var (
+	ErrHoldNotFound  = errors.New("hold not found")
+	ErrHoldNotActive = errors.New("hold is not active")
+)
+
+// HeldTx returns the total of active holds on a balance
+func HeldTx(tx *gorm.DB, key Key) (int64, error) {
+	var held int64
+	err := tx.Model(&Hold{}).
+		Where("account_id = ? AND currency = ? AND status = ? AND expires_at > ?", key.AccountID, key.Currency, HoldStatusActive, time.Now()).
+		Select("COALESCE(SUM(amount_cents), 0)").
+		Scan(&held).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum holds: %w", err)
+	}
+	return held, nil
+}
+
+// AvailableTx returns the ledger balance plus the credit limit minus active
+// holds
+func AvailableTx(tx *gorm.DB, b *Balance) (int64, error) {
+	held, err := HeldTx(tx, b.Key())
+	if err != nil {
+		return 0, err
+	}
+	return b.AmountCents + b.CreditLimitCents - held, nil
+}
+
+// PlaceHold reserves amount on the account's balance if it is available
+func PlaceHold(accountID uint, currency string, amount int64, reference string, ttl time.Duration) (*Hold, error) {
+	println("🔒 Provizyon oluşturuluyor, hesap ID:", accountID, "miktar:", amount, currency)
+
+	if amount <= 0 {
+		return nil, fmt.Errorf("hold amount must be positive")
+	}
+	if ttl <= 0 {
+		ttl = DefaultHoldTTL
+	}
+
+	hold := &Hold{
+		AccountID:   accountID,
+		Currency:    currency,
+		AmountCents: amount,
+		Status:      HoldStatusActive,
+		Reference:   reference,
+		ExpiresAt:   time.Now().Add(ttl),
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		key := Key{AccountID: accountID, Currency: currency}
+		locked, err := LockBalances(tx, key)
+		if err != nil {
+			return err
+		}
+
+		available, err := AvailableTx(tx, locked[key])
+		if err != nil {
+			return err
+		}
+		if available < amount {
+			println("❌ Provizyon için yetersiz bakiye:", available, "<", amount)
+			return ErrInsufficientFunds
+		}
+
+		if err := tx.Create(hold).Error; err != nil {
+			return fmt.Errorf("failed to create hold: %w", err)
+		}
+		return audit.LogTx(tx, "hold", fmt.Sprintf("%d", hold.ID), "place", fmt.Sprintf("account=%d amount=%d %s", accountID, amount, currency))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	println("✅ Provizyon oluşturuldu, ID:", hold.ID)
+	return hold, nil
+}
+
+// LockHoldTx loads an active hold with SELECT ... FOR UPDATE
+func LockHoldTx(tx *gorm.DB, holdID uint) (*Hold, error) {
+	var hold Hold
+	if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).First(&hold, holdID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrHoldNotFound
+		}
+		return nil, fmt.Errorf("failed to lock hold: %w", err)
+	}
+	if !hold.IsActive() {
+		return &hold, fmt.Errorf("%w: status=%s", ErrHoldNotActive, hold.Status)
+	}
+	return &hold, nil
+}
+
+// CaptureHoldTx closes the hold for amount inside tx. The caller debits the
+// captured amount in the same transaction; any remainder is released.
+func CaptureHoldTx(tx *gorm.DB, holdID uint, amount int64) (*Hold, error) {
+	hold, err := LockHoldTx(tx, holdID)
+	if err != nil {
+		return hold, err
+	}
+	if amount <= 0 || amount > hold.AmountCents {
+		return hold, fmt.Errorf("capture amount must be between 1 and %d", hold.AmountCents)
+	}
+
+	hold.CapturedCents = amount
+	hold.Status = HoldStatusCaptured
+	if err := tx.Save(hold).Error; err != nil {
+		return hold, fmt.Errorf("failed to capture hold: %w", err)
+	}
+	return hold, audit.LogTx(tx, "hold", fmt.Sprintf("%d", hold.ID), "capture", fmt.Sprintf("amount=%d of %d", amount, hold.AmountCents))
+}
+
+// VoidHold releases the reserved funds without moving money
+func VoidHold(holdID uint) (*Hold, error) {
+	println("🔓 Provizyon iptal ediliyor, ID:", holdID)
+
+	var hold *Hold
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		hold, err = LockHoldTx(tx, holdID)
+		if err != nil {
+			return err
+		}
+		hold.Status = HoldStatusVoided
+		if err := tx.Save(hold).Error; err != nil {
+			return fmt.Errorf("failed to void hold: %w", err)
+		}
+		return audit.LogTx(tx, "hold", fmt.Sprintf("%d", hold.ID), "void", fmt.Sprintf("amount=%d", hold.AmountCents))
+	})
+	if err != nil {
+		return hold, err
+	}
+
+	println("✅ Provizyon iptal edildi, ID:", holdID)
+	return hold, nil
+}
+
+// ExpireHolds marks every active hold past its expiry as expired
+func ExpireHolds() (int64, error) {
+	res := db.DB.Model(&Hold{}).
+		Where("status = ? AND expires_at <= ?", HoldStatusActive, time.Now()).
+		Update("status", HoldStatusExpired)
+	if res.Error != nil {
+		println("❌ Süresi dolan provizyonlar güncellenemedi:", res.Error.Error())
+		return 0, fmt.Errorf("failed to expire holds: %w", res.Error)
+	}
+	if res.RowsAffected > 0 {
+		println("⌛ Süresi dolan provizyon sayısı:", res.RowsAffected)
+	}
+	return res.RowsAffected, nil
+}
+
+// GetHold returns a hold by ID
+func GetHold(holdID uint) (*Hold, error) {
+	var hold Hold
+	if err := db.DB.First(&hold, holdID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrHoldNotFound
+		}
+		return nil, fmt.Errorf("failed to load hold: %w", err)
+	}
+	return &hold, nil
+}
+
+// ListHolds returns the holds of an account, newest first
+func ListHolds(accountID uint, status HoldStatus) ([]Hold, error) {
+	query := db.DB.Where("account_id = ?", accountID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var holds []Hold
+	if err := query.Order("id DESC").Limit(200).Find(&holds).Error; err != nil {
+		return nil, fmt.Errorf("failed to list holds: %w", err)
+	}
+	return holds, nil
+}
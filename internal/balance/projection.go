@@ -0,0 +1,147 @@
+package balance
+
+import (
+	"bankapi/internal/audit"
+	"bankapi/internal/db"
+	"bankapi/internal/events"
+	"bankapi/internal/logger"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrRebuildInProgress is returned while a projection rebuild is running
+var ErrRebuildInProgress = errors.New("a projection rebuild is already in progress")
+
+var rebuilding atomic.Bool
+
+// RebuildResult summarises a projection rebuild
+type RebuildResult struct {
+	Events         int   `json:"events"`
+	Accounts       int   `json:"accounts"`
+	Balances       int   `json:"balances"`
+	HistoryEntries int   `json:"history_entries"`
+	DurationMs     int64 `json:"duration_ms"`
+}
+
+// RebuildProjections drops the balances and their history and rebuilds both
+// by replaying every account event in the store. It runs in one database
+// transaction that blocks balance changes and appends until it commits, so
+// readers see either the old or the rebuilt projections. Holds are not
+// projections and are kept.
+func RebuildProjections() (*RebuildResult, error) {
+	if !rebuilding.CompareAndSwap(false, true) {
+		return nil, ErrRebuildInProgress
+	}
+	defer rebuilding.Store(false)
+
+	println("🔁 Bakiye projeksiyonları yeniden oluşturuluyor...")
+	started := time.Now()
+	result := &RebuildResult{}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("LOCK TABLE balances IN EXCLUSIVE MODE").Error; err != nil {
+			return fmt.Errorf("failed to lock balances: %w", err)
+		}
+		if err := events.LockAppendsTx(tx); err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM balance_histories").Error; err != nil {
+			return fmt.Errorf("failed to clear balance history: %w", err)
+		}
+		if err := tx.Exec("DELETE FROM balances").Error; err != nil {
+			return fmt.Errorf("failed to clear balances: %w", err)
+		}
+
+		store := events.NewPostgresEventStore(tx)
+		accounts := map[uint]*AccountAggregate{}
+		var history []BalanceHistory
+		var position int64
+		for {
+			page, err := store.ReadAllLocked(position, events.MaxPageSize)
+			if err != nil {
+				return err
+			}
+			for _, e := range page {
+				position = e.Position
+				accountID, ok := parseAggregateID(e.AggregateID)
+				if !ok {
+					continue
+				}
+				a, ok := accounts[accountID]
+				if !ok {
+					a = NewAccountAggregate(accountID)
+					accounts[accountID] = a
+				}
+				h, err := a.apply(e)
+				if err != nil {
+					return err
+				}
+				result.Events++
+				if h != nil {
+					history = append(history, *h)
+				}
+			}
+			if len(history) >= 500 || len(page) < events.MaxPageSize {
+				if err := insertHistory(tx, history); err != nil {
+					return err
+				}
+				result.HistoryEntries += len(history)
+				history = history[:0]
+			}
+			if len(page) < events.MaxPageSize {
+				break
+			}
+		}
+
+		balances := make([]Balance, 0, len(accounts))
+		for _, a := range accounts {
+			for _, b := range a.Balances {
+				balances = append(balances, *b)
+			}
+		}
+		sort.Slice(balances, func(i, j int) bool {
+			if balances[i].AccountID != balances[j].AccountID {
+				return balances[i].AccountID < balances[j].AccountID
+			}
+			return balances[i].Currency < balances[j].Currency
+		})
+		if len(balances) > 0 {
+			if err := tx.CreateInBatches(&balances, 500).Error; err != nil {
+				return fmt.Errorf("failed to store balances: %w", err)
+			}
+		}
+		result.Accounts = len(accounts)
+		result.Balances = len(balances)
+
+		return audit.LogTx(tx, "projection", "balances", "rebuild", fmt.Sprintf("events=%d balances=%d history=%d", result.Events, result.Balances, result.HistoryEntries))
+	})
+	result.DurationMs = time.Since(started).Milliseconds()
+	if err != nil {
+		logger.Error("Projection rebuild failed", err, nil)
+		return nil, err
+	}
+
+	logger.Info("Balance projections rebuilt", map[string]interface{}{
+		"events":          result.Events,
+		"balances":        result.Balances,
+		"history_entries": result.HistoryEntries,
+		"duration_ms":     result.DurationMs,
+	})
+	println("✅ Bakiye projeksiyonları yeniden oluşturuldu, event sayısı:", result.Events)
+	return result, nil
+}
+
+func insertHistory(tx *gorm.DB, history []BalanceHistory) error {
+	if len(history) == 0 {
+		return nil
+	}
+	if err := tx.CreateInBatches(&history, 500).Error; err != nil {
+		return fmt.Errorf("failed to store balance history: %w", err)
+	}
+	return nil
+}
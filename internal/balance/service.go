@@ -3,113 +3,322 @@ package balance
 import (
 	"bankapi/internal/audit"
 	"bankapi/internal/db"
+	"bankapi/internal/events"
 	"fmt"
-	"sync"
+	"sort"
 	"time"
-)
 
-var mu sync.RWMutex
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
 
-func GetOrCreateBalance(userID uint) (Balance, error) {
-	println("💰 Bakiye alınıyor/oluşturuluyor, kullanıcı ID:", userID)
+func GetOrCreateBalance(accountID uint, currency string) (Balance, error) {
+	println("💰 Bakiye alınıyor/oluşturuluyor, hesap ID:", accountID, "para birimi:", currency)
 
 	var b Balance
-	err := db.DB.First(&b, "user_id = ?", userID).Error
+	err := db.DB.First(&b, "account_id = ? AND currency = ?", accountID, currency).Error
 	if err == nil {
 		println("✅ Mevcut bakiye bulundu:", b.AmountCents, "kuruş")
 		return b, nil
 	}
 
 	println("🆕 Yeni bakiye oluşturuluyor...")
-	b = Balance{UserID: userID, AmountCents: 0, LastUpdated: time.Now()}
-	if err := db.DB.Create(&b).Error; err != nil {
+	if err := ensureBalances(db.DB, []Key{{AccountID: accountID, Currency: currency}}); err != nil {
 		println("❌ Bakiye oluşturulamadı:", err.Error())
 		return Balance{}, fmt.Errorf("failed to create balance: %w", err)
 	}
+	if err := db.DB.First(&b, "account_id = ? AND currency = ?", accountID, currency).Error; err != nil {
+		return Balance{}, fmt.Errorf("failed to load balance: %w", err)
+	}
 
 	println("✅ Yeni bakiye oluşturuldu")
 	return b, nil
 }
 
-func Credit(userID uint, amount int64) error {
-	println("💳 Kredi işlemi, kullanıcı ID:", userID, "miktar:", amount, "kuruş")
+// BalanceView is a balance together with what is currently spendable
+type BalanceView struct {
+	Balance
+	HeldCents      int64 `json:"held_cents"`
+	AvailableCents int64 `json:"available_cents"`
+}
+
+// GetBalanceView returns the balance with its held and available amounts.
+// Available is balance + credit limit - holds.
+func GetBalanceView(accountID uint, currency string) (BalanceView, error) {
+	b, err := GetOrCreateBalance(accountID, currency)
+	if err != nil {
+		return BalanceView{}, err
+	}
+	held, err := HeldTx(db.DB, b.Key())
+	if err != nil {
+		return BalanceView{}, err
+	}
+	return BalanceView{Balance: b, HeldCents: held, AvailableCents: b.AmountCents + b.CreditLimitCents - held}, nil
+}
+
+// GetBalances returns every currency balance of an account
+func GetBalances(accountID uint) ([]Balance, error) {
+	var balances []Balance
+	if err := db.DB.Where("account_id = ?", accountID).Order("currency").Find(&balances).Error; err != nil {
+		return nil, fmt.Errorf("failed to load balances: %w", err)
+	}
+	return balances, nil
+}
+
+// ensureBalances creates missing balance rows without touching existing ones.
+func ensureBalances(tx *gorm.DB, keys []Key) error {
+	rows := make([]Balance, 0, len(keys))
+	for _, k := range keys {
+		rows = append(rows, Balance{AccountID: k.AccountID, Currency: k.Currency, AmountCents: 0, LastUpdated: time.Now()})
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+// LockBalances loads the given balances with SELECT ... FOR UPDATE. Rows are
+// always locked in (account_id, currency) order so that concurrent transfers
+// between the same accounts, on any replica, cannot deadlock. The history
+// sequence of every account involved is locked afterwards, again in order.
+func LockBalances(tx *gorm.DB, keys ...Key) (map[Key]*Balance, error) {
+	keys = uniqueSorted(keys)
+	if len(keys) == 0 {
+		return map[Key]*Balance{}, nil
+	}
+	if err := ensureBalances(tx, keys); err != nil {
+		return nil, fmt.Errorf("failed to create balances: %w", err)
+	}
+
+	pairs := make([][]interface{}, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, []interface{}{k.AccountID, k.Currency})
+	}
+
+	var rows []Balance
+	if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Where("(account_id, currency) IN ?", pairs).
+		Order("account_id ASC, currency ASC").
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock balances: %w", err)
+	}
+
+	locked := make(map[Key]*Balance, len(rows))
+	for i := range rows {
+		locked[rows[i].Key()] = &rows[i]
+	}
+	for _, k := range keys {
+		if _, ok := locked[k]; !ok {
+			return nil, fmt.Errorf("balance not found for account %d in %s", k.AccountID, k.Currency)
+		}
+	}
+
+	var last uint
+	for _, k := range keys {
+		if k.AccountID == last {
+			continue
+		}
+		last = k.AccountID
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", sequenceLockClass, int32(k.AccountID)).Error; err != nil {
+			return nil, fmt.Errorf("failed to lock balance history sequence: %w", err)
+		}
+	}
+	return locked, nil
+}
+
+// sequenceLockClass namespaces the advisory locks that serialise the history
+// sequence of an account
+const sequenceLockClass = 4201
+
+// CreditTx adds amount to the account's balance in currency inside tx. The
+// balance row is locked for the rest of the transaction.
+func CreditTx(tx *gorm.DB, accountID uint, currency string, amount int64, entry Entry) error {
+	println("💳 Kredi işlemi (tx), hesap ID:", accountID, "miktar:", amount, currency)
 
 	if amount <= 0 {
 		println("❌ Geçersiz kredi miktarı:", amount)
 		return fmt.Errorf("credit amount must be positive")
 	}
 
-	mu.Lock()
-	defer mu.Unlock()
-
-	var b Balance
-	if err := db.DB.FirstOrCreate(&b, Balance{UserID: userID}).Error; err != nil {
-		println("❌ Bakiye alınamadı/oluşturulamadı:", err.Error())
-		return fmt.Errorf("failed to get/create balance: %w", err)
+	key := Key{AccountID: accountID, Currency: currency}
+	locked, err := LockBalances(tx, key)
+	if err != nil {
+		return err
 	}
+	b := locked[key]
 
 	oldAmount := b.AmountCents
 	b.AmountCents += amount
-	b.LastUpdated = time.Now()
-
-	if err := db.DB.Save(&b).Error; err != nil {
-		println("❌ Bakiye güncellenemedi:", err.Error())
-		return fmt.Errorf("failed to update balance: %w", err)
+	if err := saveWithHistory(tx, b, amount, entry); err != nil {
+		return err
 	}
 
-	// Create balance history
-	if err := db.DB.Create(&BalanceHistory{UserID: userID, AmountCents: b.AmountCents}).Error; err != nil {
-		println("⚠️ Bakiye geçmişi oluşturulamadı:", err.Error())
+	if err := audit.LogTx(tx, "balance", fmt.Sprintf("%d", accountID), "credit", fmt.Sprintf("+%d %s -> %d", amount, currency, b.AmountCents)); err != nil {
+		return err
 	}
 
-	// Audit log
-	audit.Log("balance", fmt.Sprintf("%d", userID), "credit", fmt.Sprintf("+%d -> %d", amount, b.AmountCents))
-
-	println("✅ Kredi işlemi başarılı:", oldAmount, "->", b.AmountCents, "kuruş")
+	println("✅ Kredi işlemi başarılı:", oldAmount, "->", b.AmountCents, currency)
 	return nil
 }
 
-func Debit(userID uint, amount int64) error {
-	println("💸 Debit işlemi, kullanıcı ID:", userID, "miktar:", amount, "kuruş")
+// DebitTx subtracts amount from the account's balance in currency inside tx and
+// returns ErrInsufficientFunds if it exceeds the available balance (the
+// ledger balance plus the credit limit minus active holds).
+func DebitTx(tx *gorm.DB, accountID uint, currency string, amount int64, entry Entry) error {
+	println("💸 Debit işlemi (tx), hesap ID:", accountID, "miktar:", amount, currency)
 
 	if amount <= 0 {
 		println("❌ Geçersiz debit miktarı:", amount)
 		return fmt.Errorf("debit amount must be positive")
 	}
 
-	mu.Lock()
-	defer mu.Unlock()
-
-	var b Balance
-	if err := db.DB.FirstOrCreate(&b, Balance{UserID: userID}).Error; err != nil {
-		println("❌ Bakiye alınamadı/oluşturulamadı:", err.Error())
-		return fmt.Errorf("failed to get/create balance: %w", err)
+	key := Key{AccountID: accountID, Currency: currency}
+	locked, err := LockBalances(tx, key)
+	if err != nil {
+		return err
 	}
+	b := locked[key]
 
-	if b.AmountCents < amount {
-		println("❌ Yetersiz bakiye:", b.AmountCents, "<", amount)
+	available, err := AvailableTx(tx, b)
+	if err != nil {
+		return err
+	}
+	if available < amount {
+		println("❌ Yetersiz kullanılabilir bakiye:", available, "<", amount)
 		return ErrInsufficientFunds
 	}
 
 	oldAmount := b.AmountCents
 	b.AmountCents -= amount
-	b.LastUpdated = time.Now()
+	if err := saveWithHistory(tx, b, -amount, entry); err != nil {
+		return err
+	}
+
+	if err := audit.LogTx(tx, "balance", fmt.Sprintf("%d", accountID), "debit", fmt.Sprintf("-%d %s -> %d", amount, currency, b.AmountCents)); err != nil {
+		return err
+	}
+
+	println("✅ Debit işlemi başarılı:", oldAmount, "->", b.AmountCents, currency)
+	return nil
+}
 
-	if err := db.DB.Save(&b).Error; err != nil {
+// ChargeTx subtracts amount from the account's balance inside tx without
+// checking what is available. It is meant for bank charges such as overdraft
+// interest, which are booked even when they take the balance past its limit.
+func ChargeTx(tx *gorm.DB, accountID uint, currency string, amount int64, entry Entry) error {
+	println("💸 Masraf işlemi (tx), hesap ID:", accountID, "miktar:", amount, currency)
+
+	if amount <= 0 {
+		return fmt.Errorf("charge amount must be positive")
+	}
+
+	key := Key{AccountID: accountID, Currency: currency}
+	locked, err := LockBalances(tx, key)
+	if err != nil {
+		return err
+	}
+	b := locked[key]
+
+	b.AmountCents -= amount
+	if err := saveWithHistory(tx, b, -amount, entry); err != nil {
+		return err
+	}
+	return audit.LogTx(tx, "balance", fmt.Sprintf("%d", accountID), "charge", fmt.Sprintf("-%d %s -> %d", amount, currency, b.AmountCents))
+}
+
+// EventChanged is queued in the outbox for every balance change
+const EventChanged = "balance.changed"
+
+// saveWithHistory records the change of the balance by delta as an event of
+// the account and updates the balance and history projections and the
+// balance.changed outbox event in the same tx. The caller holds the
+// account's sequence lock through LockBalances.
+func saveWithHistory(tx *gorm.DB, b *Balance, delta int64, entry Entry) error {
+	now := time.Now()
+	eventType, amount := EventCredited, delta
+	if delta < 0 {
+		eventType, amount = EventDebited, -delta
+	}
+	data := map[string]interface{}{
+		"currency":     b.Currency,
+		"amount_cents": amount,
+		"entry_type":   entry.Type,
+	}
+	if entry.TransactionID != nil {
+		data["transaction_id"] = *entry.TransactionID
+	}
+	if err := appendEventTx(tx, b.AccountID, eventType, now, data); err != nil {
+		return err
+	}
+
+	b.LastUpdated = now
+	if err := tx.Save(b).Error; err != nil {
 		println("❌ Bakiye güncellenemedi:", err.Error())
 		return fmt.Errorf("failed to update balance: %w", err)
 	}
 
-	// Create balance history
-	if err := db.DB.Create(&BalanceHistory{UserID: userID, AmountCents: b.AmountCents}).Error; err != nil {
-		println("⚠️ Bakiye geçmişi oluşturulamadı:", err.Error())
+	var seq int64
+	if err := tx.Model(&BalanceHistory{}).Where("account_id = ?", b.AccountID).
+		Select("COALESCE(MAX(sequence), 0)").Scan(&seq).Error; err != nil {
+		return fmt.Errorf("failed to read balance history sequence: %w", err)
 	}
 
-	// Audit log
-	audit.Log("balance", fmt.Sprintf("%d", userID), "debit", fmt.Sprintf("-%d -> %d", amount, b.AmountCents))
+	h := &BalanceHistory{
+		AccountID:     b.AccountID,
+		Sequence:      seq + 1,
+		Currency:      b.Currency,
+		TransactionID: entry.TransactionID,
+		EntryType:     entry.Type,
+		DeltaCents:    delta,
+		PreviousCents: b.AmountCents - delta,
+		AmountCents:   b.AmountCents,
+		CreatedAt:     now,
+	}
+	if err := tx.Create(h).Error; err != nil {
+		println("❌ Bakiye geçmişi oluşturulamadı:", err.Error())
+		return fmt.Errorf("failed to create balance history: %w", err)
+	}
 
-	println("✅ Debit işlemi başarılı:", oldAmount, "->", b.AmountCents, "kuruş")
-	return nil
+	event := events.NewEvent(EventChanged, fmt.Sprintf("%d", b.AccountID), map[string]interface{}{
+		"account_id":     h.AccountID,
+		"currency":       h.Currency,
+		"sequence":       h.Sequence,
+		"transaction_id": h.TransactionID,
+		"entry_type":     h.EntryType,
+		"delta_cents":    h.DeltaCents,
+		"previous_cents": h.PreviousCents,
+		"amount_cents":   h.AmountCents,
+	})
+	event.Version = int(h.Sequence)
+	return events.EnqueueTx(tx, event)
+}
+
+func Credit(accountID uint, currency string, amount int64, entry Entry) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		return CreditTx(tx, accountID, currency, amount, entry)
+	})
+}
+
+func Debit(accountID uint, currency string, amount int64, entry Entry) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		return DebitTx(tx, accountID, currency, amount, entry)
+	})
+}
+
+func uniqueSorted(keys []Key) []Key {
+	seen := make(map[Key]struct{}, len(keys))
+	out := make([]Key, 0, len(keys))
+	for _, k := range keys {
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		out = append(out, k)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].AccountID != out[j].AccountID {
+			return out[i].AccountID < out[j].AccountID
+		}
+		return out[i].Currency < out[j].Currency
+	})
+	return out
 }
 
 var ErrInsufficientFunds = &insufficientFundsError{}
@@ -0,0 +1,206 @@
+package balance
+
+import (
+	"bankapi/internal/db"
+	"bankapi/internal/logger"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Interval string
+
+const (
+	IntervalDay   Interval = "day"
+	IntervalWeek  Interval = "week"
+	IntervalMonth Interval = "month"
+
+	// maxSeriesPoints bounds the length of a series
+	maxSeriesPoints = 1000
+)
+
+var (
+	// ErrNoBalance is returned for a point in time before the balance existed
+	ErrNoBalance = errors.New("no balance recorded at that time")
+	// ErrSeriesTooLong is returned for a range with too many points
+	ErrSeriesTooLong = fmt.Errorf("series is longer than %d points", maxSeriesPoints)
+)
+
+// Snapshot is the closing balance of a day. LastSequence is the sequence of
+// the last history entry of the balance before the day ended, so later
+// entries can be added on top of it.
+type Snapshot struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	AccountID    uint      `json:"account_id" gorm:"not null;uniqueIndex:idx_balance_snapshot_day"`
+	Currency     string    `json:"currency" gorm:"size:3;not null;uniqueIndex:idx_balance_snapshot_day"`
+	SnapshotDate time.Time `json:"snapshot_date" gorm:"type:date;not null;uniqueIndex:idx_balance_snapshot_day"`
+	ClosingCents int64     `json:"closing_cents"`
+	LastSequence int64     `json:"last_sequence"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (Snapshot) TableName() string { return "balance_snapshots" }
+
+// SeriesPoint is the closing balance of one period
+type SeriesPoint struct {
+	Date         string `json:"date"` // last day of the period
+	ClosingCents int64  `json:"closing_cents"`
+}
+
+// SnapshotDay stores the closing balance of every balance that existed by
+// the end of day. When the previous day has snapshots only that day's
+// history is read on top of them; otherwise the history is scanned from the
+// start. Days already snapshotted are left alone.
+func SnapshotDay(day time.Time) (int64, error) {
+	start := startOfDay(day)
+	end := start.AddDate(0, 0, 1)
+	println("📸 Gün sonu bakiye görüntüsü alınıyor:", start.Format("2006-01-02"))
+
+	closing := map[Key]*Snapshot{}
+	var previous []Snapshot
+	if err := db.DB.Where("snapshot_date = ?", start.AddDate(0, 0, -1)).Find(&previous).Error; err != nil {
+		return 0, fmt.Errorf("failed to load previous snapshots: %w", err)
+	}
+	since := time.Time{}
+	if len(previous) > 0 {
+		since = start
+		for _, p := range previous {
+			closing[Key{AccountID: p.AccountID, Currency: p.Currency}] = &Snapshot{
+				AccountID: p.AccountID, Currency: p.Currency, ClosingCents: p.ClosingCents, LastSequence: p.LastSequence,
+			}
+		}
+	}
+
+	var latest []BalanceHistory
+	if err := db.DB.Raw(`SELECT DISTINCT ON (account_id, currency) account_id, currency, sequence, amount_cents
+		FROM balance_histories WHERE created_at >= ? AND created_at < ?
+		ORDER BY account_id, currency, sequence DESC`, since, end).Scan(&latest).Error; err != nil {
+		return 0, fmt.Errorf("failed to load closing balances: %w", err)
+	}
+	for _, h := range latest {
+		closing[Key{AccountID: h.AccountID, Currency: h.Currency}] = &Snapshot{
+			AccountID: h.AccountID, Currency: h.Currency, ClosingCents: h.AmountCents, LastSequence: h.Sequence,
+		}
+	}
+	if len(closing) == 0 {
+		return 0, nil
+	}
+
+	rows := make([]Snapshot, 0, len(closing))
+	for _, s := range closing {
+		s.SnapshotDate = start
+		rows = append(rows, *s)
+	}
+	res := db.DB.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&rows, 500)
+	if res.Error != nil {
+		return 0, fmt.Errorf("failed to store snapshots: %w", res.Error)
+	}
+
+	logger.Info("Balance snapshots taken", map[string]interface{}{
+		"date":      start.Format("2006-01-02"),
+		"snapshots": res.RowsAffected,
+	})
+	return res.RowsAffected, nil
+}
+
+// BalanceAt returns the balance at t: the closing snapshot of the last day
+// before t plus every change recorded after it up to t.
+func BalanceAt(accountID uint, currency string, t time.Time) (int64, error) {
+	var snap Snapshot
+	err := db.DB.Where("account_id = ? AND currency = ? AND snapshot_date < ?", accountID, currency, startOfDay(t)).
+		Order("snapshot_date DESC").
+		First(&snap).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, fmt.Errorf("failed to load snapshot: %w", err)
+	}
+	found := err == nil
+
+	var delta struct {
+		Count int64
+		Sum   int64
+	}
+	if err := db.DB.Model(&BalanceHistory{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(delta_cents), 0) AS sum").
+		Where("account_id = ? AND currency = ? AND sequence > ? AND created_at <= ?", accountID, currency, snap.LastSequence, t).
+		Scan(&delta).Error; err != nil {
+		return 0, fmt.Errorf("failed to sum balance changes: %w", err)
+	}
+	if !found && delta.Count == 0 {
+		return 0, ErrNoBalance
+	}
+	return snap.ClosingCents + delta.Sum, nil
+}
+
+// Series returns the closing balance at the end of every interval between
+// from and to (both dates, inclusive). A period still running closes at to.
+// Days with a snapshot are read from it; the rest are computed.
+func Series(accountID uint, currency string, from, to time.Time, interval Interval) ([]SeriesPoint, error) {
+	from, to = startOfDay(from), startOfDay(to)
+	if to.Before(from) {
+		return nil, fmt.Errorf("to must not be before from")
+	}
+
+	var ends []time.Time
+	for d := periodEnd(from, interval); ; d = periodEnd(d.AddDate(0, 0, 1), interval) {
+		if d.After(to) {
+			ends = append(ends, to)
+			break
+		}
+		ends = append(ends, d)
+		if d.Equal(to) {
+			break
+		}
+		if len(ends) > maxSeriesPoints {
+			return nil, fmt.Errorf("series is longer than %d points", maxSeriesPoints)
+		}
+	}
+
+	var snaps []Snapshot
+	if err := db.DB.Where("account_id = ? AND currency = ? AND snapshot_date >= ? AND snapshot_date <= ?", accountID, currency, from, to).
+		Find(&snaps).Error; err != nil {
+		return nil, fmt.Errorf("failed to load snapshots: %w", err)
+	}
+	byDate := make(map[string]int64, len(snaps))
+	for _, s := range snaps {
+		byDate[s.SnapshotDate.Format("2006-01-02")] = s.ClosingCents
+	}
+
+	points := make([]SeriesPoint, 0, len(ends))
+	for _, d := range ends {
+		date := d.Format("2006-01-02")
+		closing, ok := byDate[date]
+		if !ok {
+			var err error
+			closing, err = BalanceAt(accountID, currency, d.AddDate(0, 0, 1).Add(-time.Nanosecond))
+			if errors.Is(err, ErrNoBalance) {
+				closing, err = 0, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		points = append(points, SeriesPoint{Date: date, ClosingCents: closing})
+	}
+	return points, nil
+}
+
+// periodEnd returns the last day of the interval d falls in. Weeks end on
+// Sunday.
+func periodEnd(d time.Time, interval Interval) time.Time {
+	switch interval {
+	case IntervalWeek:
+		return d.AddDate(0, 0, (7-int(d.Weekday()))%7)
+	case IntervalMonth:
+		return time.Date(d.Year(), d.Month()+1, 1, 0, 0, 0, 0, d.Location()).AddDate(0, 0, -1)
+	default:
+		return d
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
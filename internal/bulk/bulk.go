@@ -0,0 +1,112 @@
+package bulk
+
+import (
+	"strings"
+	"time"
+)
+
+type Format string
+type BatchStatus string
+type LineStatus string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatPain001 Format = "pain001"
+
+	BatchStatusRejected           BatchStatus = "rejected"  // validation failed, nothing ran
+	BatchStatusValidated          BatchStatus = "validated" // dry run
+	BatchStatusProcessing         BatchStatus = "processing"
+	BatchStatusCompleted          BatchStatus = "completed"
+	BatchStatusPartiallyCompleted BatchStatus = "partially_completed"
+	BatchStatusFailed             BatchStatus = "failed"
+
+	LineStatusInvalid   LineStatus = "invalid"
+	LineStatusSkipped   LineStatus = "skipped" // valid, but the batch did not run
+	LineStatusQueued    LineStatus = "queued"
+	LineStatusCompleted LineStatus = "completed"
+	LineStatusReview    LineStatus = "pending_review"
+	LineStatusFailed    LineStatus = "failed"
+)
+
+// MaxLines caps the number of instructions in one file
+const MaxLines = 1000
+
+// Batch is one uploaded payment file and its outcome
+type Batch struct {
+	ID               uint        `json:"id" gorm:"primaryKey"`
+	Reference        string      `json:"reference" gorm:"size:64;index"` // worker batch ID
+	MessageID        string      `json:"message_id" gorm:"size:64"`
+	UploadedBy       uint        `json:"uploaded_by" gorm:"index"`
+	FileName         string      `json:"file_name" gorm:"size:255"`
+	Format           Format      `json:"format" gorm:"size:10;not null"`
+	Status           BatchStatus `json:"status" gorm:"size:20;index;not null"`
+	TotalLines       int         `json:"total_lines"`
+	ValidLines       int         `json:"valid_lines"`
+	InvalidLines     int         `json:"invalid_lines"`
+	SucceededLines   int         `json:"succeeded_lines"`
+	FailedLines      int         `json:"failed_lines"`
+	TotalAmountCents int64       `json:"total_amount_cents"`
+	Error            string      `json:"error,omitempty" gorm:"size:255"`
+	Lines            []Line      `json:"lines,omitempty" gorm:"foreignKey:BatchID"`
+	CreatedAt        time.Time   `json:"created_at" gorm:"index"`
+	UpdatedAt        time.Time   `json:"updated_at"`
+	CompletedAt      *time.Time  `json:"completed_at,omitempty"`
+}
+
+func (Batch) TableName() string { return "bulk_batches" }
+
+// Line is one transfer instruction with its validation and execution result
+type Line struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	BatchID       uint       `json:"batch_id" gorm:"index;not null"`
+	LineNo        int        `json:"line_no"`
+	EndToEndID    string     `json:"end_to_end_id" gorm:"size:35;index"`
+	FromAccount   string     `json:"from_account" gorm:"size:64"`
+	ToAccount     string     `json:"to_account" gorm:"size:64"`
+	FromAccountID uint       `json:"from_account_id"`
+	ToAccountID   uint       `json:"to_account_id"`
+	AmountCents   int64      `json:"amount_cents"`
+	Currency      string     `json:"currency" gorm:"size:3"`
+	Remittance    string     `json:"remittance,omitempty" gorm:"size:140"`
+	Status        LineStatus `json:"status" gorm:"size:20;not null"`
+	Errors        string     `json:"errors,omitempty" gorm:"type:text"`
+	TransactionID *uint      `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (Line) TableName() string { return "bulk_lines" }
+
+// Instruction is a parsed, not yet validated, transfer from the file
+type Instruction struct {
+	LineNo      int
+	EndToEndID  string
+	FromAccount string
+	ToAccount   string
+	Amount      string
+	Currency    string
+	Remittance  string
+}
+
+// line turns the instruction into a Line carrying its validation errors
+func (in Instruction) line(errs []string) Line {
+	l := Line{
+		LineNo:      in.LineNo,
+		EndToEndID:  in.EndToEndID,
+		FromAccount: in.FromAccount,
+		ToAccount:   in.ToAccount,
+		Currency:    strings.ToUpper(in.Currency),
+		Remittance:  in.Remittance,
+		Status:      LineStatusQueued,
+	}
+	if len(errs) > 0 {
+		l.Status = LineStatusInvalid
+		l.Errors = strings.Join(errs, "; ")
+	}
+	return l
+}
+
+// Valid reports whether the line passed validation
+func (l Line) Valid() bool {
+	return l.Status != LineStatusInvalid
+}
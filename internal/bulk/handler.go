@@ -0,0 +1,133 @@
+package bulk
+
+import (
+	"bankapi/internal/middleware"
+	"bankapi/internal/worker"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxFileSize caps the size of an uploaded payment file
+const maxFileSize = 5 << 20
+
+type Handler struct {
+	processor *worker.BatchProcessor
+}
+
+func NewHandler(bp *worker.BatchProcessor) *Handler {
+	return &Handler{processor: bp}
+}
+
+// Upload imports a multipart "file" as CSV or pain.001. ?format overrides
+// detection, ?dry_run=true only validates and ?skip_invalid=true runs the
+// valid lines of a file that also has invalid ones.
+func (h *Handler) Upload(c *gin.Context) {
+	u, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Kimlik doğrulaması gerekli"})
+		return
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file alanı gerekli"})
+		return
+	}
+	if fh.Size > maxFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "dosya çok büyük"})
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dosya okunamadı"})
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxFileSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dosya okunamadı"})
+		return
+	}
+
+	opts := ImportOptions{
+		FileName:    fh.Filename,
+		Format:      c.DefaultQuery("format", c.PostForm("format")),
+		DryRun:      c.Query("dry_run") == "true",
+		SkipInvalid: c.Query("skip_invalid") == "true",
+	}
+	batch, err := Import(h.processor, u, data, opts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch batch.Status {
+	case BatchStatusRejected:
+		c.JSON(http.StatusUnprocessableEntity, batch)
+	case BatchStatusValidated:
+		c.JSON(http.StatusOK, batch)
+	default:
+		c.JSON(http.StatusAccepted, batch)
+	}
+}
+
+// ListBatches returns uploaded files, newest first. Non-admins only see
+// their own uploads.
+func (h *Handler) ListBatches(c *gin.Context) {
+	u, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Kimlik doğrulaması gerekli"})
+		return
+	}
+	limit := 100
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit geçersiz"})
+			return
+		}
+		limit = n
+	}
+	var uploadedBy uint
+	if !u.IsAdmin() {
+		uploadedBy = u.ID
+	}
+	batches, err := List(uploadedBy, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "toplu ödemeler getirilemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, batches)
+}
+
+// GetBatch returns a batch with the status and result of every line
+func (h *Handler) GetBatch(c *gin.Context) {
+	u, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Kimlik doğrulaması gerekli"})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "toplu ödeme ID geçersiz"})
+		return
+	}
+	batch, err := Get(uint(id))
+	if err != nil {
+		if errors.Is(err, ErrBatchNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "toplu ödeme bulunamadı"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "toplu ödeme getirilemedi"})
+		return
+	}
+	if !u.IsAdmin() && batch.UploadedBy != u.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Erişim reddedildi"})
+		return
+	}
+	c.JSON(http.StatusOK, batch)
+}
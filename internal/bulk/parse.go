@@ -0,0 +1,187 @@
+package bulk
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// DetectFormat picks the format from an explicit value, the file extension
+// or, failing both, the content
+func DetectFormat(explicit, fileName string, data []byte) (Format, error) {
+	switch strings.ToLower(explicit) {
+	case "csv":
+		return FormatCSV, nil
+	case "pain001", "pain.001", "xml":
+		return FormatPain001, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unsupported format: %s", explicit)
+	}
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".xml":
+		return FormatPain001, nil
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		return FormatPain001, nil
+	}
+	return FormatCSV, nil
+}
+
+// Parse reads the instructions of a file. It only fails when the file as a
+// whole is unreadable; per-line problems are left to validation.
+func Parse(format Format, data []byte) (string, []Instruction, error) {
+	switch format {
+	case FormatCSV:
+		instructions, err := parseCSV(data)
+		return "", instructions, err
+	case FormatPain001:
+		return parsePain001(data)
+	default:
+		return "", nil, fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+var csvColumns = []string{"from_account", "to_account", "amount", "currency", "end_to_end_id", "remittance"}
+
+// parseCSV expects a header row naming at least from_account, to_account and
+// amount. currency, end_to_end_id and remittance are optional.
+func parseCSV(data []byte) ([]Instruction, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+	}
+	for _, required := range csvColumns[:3] {
+		if _, ok := index[required]; !ok {
+			return nil, fmt.Errorf("csv header is missing %s", required)
+		}
+	}
+
+	field := func(rec []string, name string) string {
+		i, ok := index[name]
+		if !ok || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+
+	var instructions []Instruction
+	for lineNo := 2; ; lineNo++ {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read csv line %d: %w", lineNo, err)
+		}
+		if len(rec) == 1 && strings.TrimSpace(rec[0]) == "" {
+			continue
+		}
+		instructions = append(instructions, Instruction{
+			LineNo:      lineNo,
+			FromAccount: field(rec, "from_account"),
+			ToAccount:   field(rec, "to_account"),
+			Amount:      field(rec, "amount"),
+			Currency:    field(rec, "currency"),
+			EndToEndID:  field(rec, "end_to_end_id"),
+			Remittance:  field(rec, "remittance"),
+		})
+	}
+	return instructions, nil
+}
+
+type painAccount struct {
+	IBAN  string `xml:"Id>IBAN"`
+	Other string `xml:"Id>Othr>Id"`
+	Ccy   string `xml:"Ccy"`
+}
+
+func (a painAccount) id() string {
+	if a.IBAN != "" {
+		return a.IBAN
+	}
+	return a.Other
+}
+
+type painDocument struct {
+	XMLName xml.Name `xml:"Document"`
+	GrpHdr  struct {
+		MsgID    string `xml:"MsgId"`
+		NbOfTxs  string `xml:"NbOfTxs"`
+		CtrlSum  string `xml:"CtrlSum"`
+		Creation string `xml:"CreDtTm"`
+	} `xml:"CstmrCdtTrfInitn>GrpHdr"`
+	Payments []struct {
+		ID      string      `xml:"PmtInfId"`
+		Debtor  painAccount `xml:"DbtrAcct"`
+		Credits []struct {
+			EndToEndID string `xml:"PmtId>EndToEndId"`
+			Amount     struct {
+				Ccy   string `xml:"Ccy,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"Amt>InstdAmt"`
+			Creditor   painAccount `xml:"CdtrAcct"`
+			Remittance string      `xml:"RmtInf>Ustrd"`
+		} `xml:"CdtTrfTxInf"`
+	} `xml:"CstmrCdtTrfInitn>PmtInf"`
+}
+
+// parsePain001 reads an ISO 20022 pain.001.001.09 customer credit transfer
+// initiation. Each CdtTrfTxInf becomes one instruction debiting its payment
+// block's DbtrAcct; LineNo is the instruction's position in the file.
+func parsePain001(data []byte) (string, []Instruction, error) {
+	var doc painDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return "", nil, fmt.Errorf("failed to parse pain.001: %w", err)
+	}
+	if len(doc.Payments) == 0 {
+		return "", nil, fmt.Errorf("pain.001 has no PmtInf blocks")
+	}
+
+	var instructions []Instruction
+	var sum int64
+	for _, p := range doc.Payments {
+		for _, c := range p.Credits {
+			in := Instruction{
+				LineNo:      len(instructions) + 1,
+				EndToEndID:  strings.TrimSpace(c.EndToEndID),
+				FromAccount: strings.TrimSpace(p.Debtor.id()),
+				ToAccount:   strings.TrimSpace(c.Creditor.id()),
+				Amount:      strings.TrimSpace(c.Amount.Value),
+				Currency:    strings.TrimSpace(c.Amount.Ccy),
+				Remittance:  strings.TrimSpace(c.Remittance),
+			}
+			if cents, err := parseAmount(in.Amount); err == nil {
+				sum += cents
+			}
+			instructions = append(instructions, in)
+		}
+	}
+
+	// Group header totals guard against truncated or tampered files
+	if n := strings.TrimSpace(doc.GrpHdr.NbOfTxs); n != "" && n != fmt.Sprintf("%d", len(instructions)) {
+		return doc.GrpHdr.MsgID, nil, fmt.Errorf("NbOfTxs %s does not match %d transactions", n, len(instructions))
+	}
+	if cs := strings.TrimSpace(doc.GrpHdr.CtrlSum); cs != "" {
+		ctrl, err := parseAmount(cs)
+		if err != nil || ctrl != sum {
+			return doc.GrpHdr.MsgID, nil, fmt.Errorf("CtrlSum %s does not match the sum of the transactions", cs)
+		}
+	}
+	return doc.GrpHdr.MsgID, instructions, nil
+}
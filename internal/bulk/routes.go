@@ -0,0 +1,22 @@
+package bulk
+
+import (
+	"bankapi/internal/worker"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc, bp *worker.BatchProcessor) {
+	b := router.Group("/api/v1/bulk/payments")
+
+	// Only use auth middleware if it's provided
+	if authMiddleware != nil {
+		b.Use(authMiddleware)
+	}
+
+	handler := NewHandler(bp)
+
+	b.POST("", handler.Upload)
+	b.GET("", handler.ListBatches)
+	b.GET("/:id", handler.GetBatch)
+}
@@ -0,0 +1,225 @@
+package bulk
+
+import (
+	"bankapi/internal/db"
+	"bankapi/internal/logger"
+	"bankapi/internal/transaction"
+	"bankapi/internal/user"
+	"bankapi/internal/worker"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrBatchNotFound = errors.New("bulk batch not found")
+
+// ImportOptions controls how an uploaded file is handled
+type ImportOptions struct {
+	FileName    string
+	Format      string // empty means detect
+	DryRun      bool   // validate only
+	SkipInvalid bool   // run the valid lines even if some lines are invalid
+}
+
+// Import parses and validates a payment file, stores the batch with its
+// validation report and, unless the file is rejected or this is a dry run,
+// hands the valid lines to the batch processor. Execution results are
+// written back to the lines once the batch finishes.
+func Import(bp *worker.BatchProcessor, uploader user.User, data []byte, opts ImportOptions) (*Batch, error) {
+	format, err := DetectFormat(opts.Format, opts.FileName, data)
+	if err != nil {
+		return nil, err
+	}
+	msgID, instructions, err := Parse(format, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(instructions) == 0 {
+		return nil, fmt.Errorf("file contains no payment instructions")
+	}
+	if len(instructions) > MaxLines {
+		return nil, fmt.Errorf("file has %d instructions, at most %d are allowed", len(instructions), MaxLines)
+	}
+
+	lines, err := Validate(instructions, uploader, !uploader.IsAdmin())
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &Batch{
+		MessageID:  msgID,
+		UploadedBy: uploader.ID,
+		FileName:   opts.FileName,
+		Format:     format,
+		TotalLines: len(lines),
+		Lines:      lines,
+	}
+	for _, l := range lines {
+		if l.Valid() {
+			batch.ValidLines++
+			batch.TotalAmountCents += l.AmountCents
+		} else {
+			batch.InvalidLines++
+		}
+	}
+
+	run := !opts.DryRun && batch.ValidLines > 0 && (batch.InvalidLines == 0 || opts.SkipInvalid)
+	switch {
+	case opts.DryRun:
+		batch.Status = BatchStatusValidated
+	case !run:
+		batch.Status = BatchStatusRejected
+		batch.Error = fmt.Sprintf("%d of %d lines are invalid", batch.InvalidLines, batch.TotalLines)
+	default:
+		batch.Status = BatchStatusProcessing
+	}
+	if !run {
+		for i := range batch.Lines {
+			if batch.Lines[i].Valid() {
+				batch.Lines[i].Status = LineStatusSkipped
+			}
+		}
+	}
+
+	if err := db.DB.Create(batch).Error; err != nil {
+		return nil, fmt.Errorf("failed to save bulk batch: %w", err)
+	}
+	if !run {
+		return batch, nil
+	}
+
+	// Only valid lines go to the processor; queued keeps their position
+	var queued []int
+	var txs []transaction.Transaction
+	for i, l := range batch.Lines {
+		if !l.Valid() {
+			continue
+		}
+		from, to := l.FromAccountID, l.ToAccountID
+		queued = append(queued, i)
+		txs = append(txs, transaction.Transaction{
+			FromAccountID: &from,
+			ToAccountID:   &to,
+			AmountCents:   l.AmountCents,
+			Currency:      l.Currency,
+			ToCurrency:    l.Currency,
+			Type:          transaction.TransactionTypeTransfer,
+		})
+	}
+
+	wb, err := bp.ProcessBatch(context.Background(), txs)
+	if err != nil {
+		batch.Status = BatchStatusFailed
+		batch.Error = err.Error()
+		db.DB.Model(batch).Updates(map[string]interface{}{"status": batch.Status, "error": batch.Error})
+		return batch, nil
+	}
+
+	batch.Reference = wb.ID
+	if err := db.DB.Model(batch).Update("reference", wb.ID).Error; err != nil {
+		logger.Error("Failed to store bulk batch reference", err, map[string]interface{}{"bulk_batch_id": batch.ID})
+	}
+
+	go finish(batch.ID, queued, wb)
+	return batch, nil
+}
+
+// finish waits for the worker batch and records the outcome of every line
+func finish(batchID uint, queued []int, wb *worker.BatchTransaction) {
+	<-wb.Done
+
+	var batch Batch
+	if err := db.DB.Preload("Lines", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("line_no")
+	}).First(&batch, batchID).Error; err != nil {
+		logger.Error("Failed to load bulk batch", err, map[string]interface{}{"bulk_batch_id": batchID})
+		return
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var succeeded, failed int
+		for n, i := range queued {
+			line := &batch.Lines[i]
+			res := wb.Results[n]
+			switch {
+			case res == nil:
+				line.Status = LineStatusFailed
+				line.Errors = "no result from processor"
+			case !res.Success:
+				line.Status = LineStatusFailed
+				line.Errors = res.Error
+			case res.Transaction.Status == transaction.TransactionStatusPendingReview:
+				line.Status = LineStatusReview
+			default:
+				line.Status = LineStatusCompleted
+			}
+			if res != nil && res.Transaction.ID != 0 {
+				id := res.Transaction.ID
+				line.TransactionID = &id
+			}
+			if line.Status == LineStatusFailed {
+				failed++
+			} else {
+				succeeded++
+			}
+			if err := tx.Model(line).Updates(map[string]interface{}{
+				"status":         line.Status,
+				"errors":         line.Errors,
+				"transaction_id": line.TransactionID,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		status := BatchStatusCompleted
+		switch {
+		case succeeded == 0:
+			status = BatchStatusFailed
+		case failed > 0 || batch.InvalidLines > 0:
+			status = BatchStatusPartiallyCompleted
+		}
+		return tx.Model(&batch).Updates(map[string]interface{}{
+			"status":          status,
+			"succeeded_lines": succeeded,
+			"failed_lines":    failed,
+			"completed_at":    now,
+		}).Error
+	})
+	if err != nil {
+		logger.Error("Failed to record bulk batch results", err, map[string]interface{}{"bulk_batch_id": batchID})
+		return
+	}
+
+	logger.Info("Bulk batch completed", map[string]interface{}{
+		"bulk_batch_id": batchID,
+		"reference":     wb.ID,
+	})
+}
+
+// List returns the newest batches first. A non-zero uploadedBy limits the
+// list to that uploader.
+func List(uploadedBy uint, limit int) ([]Batch, error) {
+	q := db.DB.Model(&Batch{})
+	if uploadedBy != 0 {
+		q = q.Where("uploaded_by = ?", uploadedBy)
+	}
+	var batches []Batch
+	err := q.Order("created_at DESC").Limit(limit).Find(&batches).Error
+	return batches, err
+}
+
+// Get returns a batch with its lines in file order
+func Get(id uint) (*Batch, error) {
+	var batch Batch
+	err := db.DB.Preload("Lines", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("line_no")
+	}).First(&batch, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBatchNotFound
+	}
+	return &batch, err
+}
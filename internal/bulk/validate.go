@@ -0,0 +1,230 @@
+package bulk
+
+import (
+	"bankapi/internal/account"
+	"bankapi/internal/currency"
+	"bankapi/internal/db"
+	"bankapi/internal/transaction"
+	"bankapi/internal/user"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// accountRef is an account given either by ID or by IBAN
+type accountRef struct {
+	id   uint
+	iban string
+}
+
+// parseAccount reads an account reference: an IBAN or a numeric account ID
+func parseAccount(v string) (accountRef, error) {
+	if v == "" {
+		return accountRef{}, fmt.Errorf("account is empty")
+	}
+	if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+		if id == 0 {
+			return accountRef{}, fmt.Errorf("account %q is not a valid account", v)
+		}
+		return accountRef{id: uint(id)}, nil
+	}
+	info, err := account.ParseIBAN(v)
+	if err != nil {
+		return accountRef{}, fmt.Errorf("account %q is not a valid IBAN: %v", v, err)
+	}
+	if !info.Internal {
+		return accountRef{}, fmt.Errorf("account %q is not held at this bank", v)
+	}
+	return accountRef{iban: info.IBAN}, nil
+}
+
+// loadAccounts fetches the referenced accounts keyed by ID and by IBAN
+func loadAccounts(refs []accountRef) (map[uint]*account.Account, map[string]*account.Account, error) {
+	var ids []uint
+	var ibans []string
+	for _, r := range refs {
+		if r.id != 0 {
+			ids = append(ids, r.id)
+		} else if r.iban != "" {
+			ibans = append(ibans, r.iban)
+		}
+	}
+	byID := map[uint]*account.Account{}
+	byIBAN := map[string]*account.Account{}
+	if len(ids) == 0 && len(ibans) == 0 {
+		return byID, byIBAN, nil
+	}
+	var found []account.Account
+	if err := db.DB.Where("id IN ? OR iban IN ?", append(ids, 0), append(ibans, "")).Find(&found).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to load accounts: %w", err)
+	}
+	for i := range found {
+		byID[found[i].ID] = &found[i]
+		byIBAN[found[i].IBAN] = &found[i]
+	}
+	return byID, byIBAN, nil
+}
+
+// parseAmount reads a decimal amount with at most two fraction digits
+func parseAmount(v string) (int64, error) {
+	if v == "" {
+		return 0, fmt.Errorf("amount is empty")
+	}
+	whole, frac, _ := strings.Cut(v, ".")
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("amount %q has more than two decimals", v)
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+	cents, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil || strings.HasPrefix(whole, "-") || strings.HasPrefix(whole, "+") {
+		return 0, fmt.Errorf("amount %q is not a valid decimal", v)
+	}
+	return cents, nil
+}
+
+// Validate checks every instruction and returns one Line per instruction.
+// Accounts must exist and be able to pay out or receive, amounts must be
+// positive, the
+// currency supported and the line must not duplicate another one in the file
+// or a previous file's end-to-end ID from the same debtor. Amounts above the
+// maker-checker threshold are refused. When restrictDebtor is set (non-admin
+// uploaders) every line must debit the uploader's own account.
+func Validate(instructions []Instruction, uploader user.User, restrictDebtor bool) ([]Line, error) {
+	lines := make([]Line, 0, len(instructions))
+
+	type parsed struct {
+		fromRef, toRef accountRef
+		from, to       uint
+		amount         int64
+		errs           []string
+	}
+	results := make([]parsed, len(instructions))
+	var refs []accountRef
+
+	for i, in := range instructions {
+		r := &results[i]
+		var err error
+		if r.fromRef, err = parseAccount(in.FromAccount); err != nil {
+			r.errs = append(r.errs, "from_account: "+err.Error())
+		} else {
+			refs = append(refs, r.fromRef)
+		}
+		if r.toRef, err = parseAccount(in.ToAccount); err != nil {
+			r.errs = append(r.errs, "to_account: "+err.Error())
+		} else {
+			refs = append(refs, r.toRef)
+		}
+		if r.amount, err = parseAmount(in.Amount); err != nil {
+			r.errs = append(r.errs, err.Error())
+		} else if r.amount <= 0 {
+			r.errs = append(r.errs, "amount must be positive")
+		}
+		cur := strings.ToUpper(in.Currency)
+		if cur == "" {
+			cur = currency.DefaultCurrency
+			instructions[i].Currency = cur
+		}
+		if !currency.Default().IsSupported(cur) {
+			r.errs = append(r.errs, fmt.Sprintf("unsupported currency: %s", cur))
+		} else if r.amount > 0 {
+			// Bulk files are not a way around maker-checker
+			if large, err := transaction.NeedsApproval(cur, r.amount); err != nil {
+				r.errs = append(r.errs, err.Error())
+			} else if large {
+				r.errs = append(r.errs, "amount is above the approval threshold; submit it as a single transfer")
+			}
+		}
+		if len(in.EndToEndID) > 35 {
+			r.errs = append(r.errs, "end_to_end_id is longer than 35 characters")
+		}
+	}
+
+	// Resolve the accounts and check who may pay from and to them
+	byID, byIBAN, err := loadAccounts(refs)
+	if err != nil {
+		return nil, err
+	}
+	resolve := func(ref accountRef) *account.Account {
+		if ref.id != 0 {
+			return byID[ref.id]
+		}
+		return byIBAN[ref.iban]
+	}
+	for i, in := range instructions {
+		r := &results[i]
+		if r.fromRef != (accountRef{}) {
+			switch a := resolve(r.fromRef); {
+			case a == nil:
+				r.errs = append(r.errs, fmt.Sprintf("from_account: account %s not found", in.FromAccount))
+			case restrictDebtor && a.UserID != uploader.ID:
+				r.errs = append(r.errs, "from_account does not belong to the uploader")
+			case !a.CanDebit():
+				r.errs = append(r.errs, fmt.Sprintf("from_account: account is %s", a.Status))
+			default:
+				r.from = a.ID
+			}
+		}
+		if r.toRef != (accountRef{}) {
+			switch a := resolve(r.toRef); {
+			case a == nil:
+				r.errs = append(r.errs, fmt.Sprintf("to_account: account %s not found", in.ToAccount))
+			case !a.CanCredit():
+				r.errs = append(r.errs, fmt.Sprintf("to_account: account is %s", a.Status))
+			default:
+				r.to = a.ID
+			}
+		}
+		if r.from != 0 && r.from == r.to {
+			r.errs = append(r.errs, "from_account and to_account are the same")
+		}
+	}
+
+	// End-to-end IDs already used by the same debtor in earlier files
+	used := map[string]struct{}{}
+	var e2e []string
+	for _, in := range instructions {
+		if in.EndToEndID != "" {
+			e2e = append(e2e, in.EndToEndID)
+		}
+	}
+	if len(e2e) > 0 {
+		var previous []Line
+		if err := db.DB.Select("from_account_id", "end_to_end_id").
+			Where("end_to_end_id IN ? AND status <> ?", e2e, LineStatusInvalid).
+			Find(&previous).Error; err != nil {
+			return nil, fmt.Errorf("failed to check end-to-end IDs: %w", err)
+		}
+		for _, p := range previous {
+			used[fmt.Sprintf("%d|%s", p.FromAccountID, p.EndToEndID)] = struct{}{}
+		}
+	}
+
+	seenE2E := map[string]int{}
+	seenLine := map[string]int{}
+	for i, in := range instructions {
+		r := &results[i]
+		if in.EndToEndID != "" {
+			if first, ok := seenE2E[in.EndToEndID]; ok {
+				r.errs = append(r.errs, fmt.Sprintf("duplicate end_to_end_id of line %d", first))
+			} else {
+				seenE2E[in.EndToEndID] = in.LineNo
+			}
+			if _, ok := used[fmt.Sprintf("%d|%s", r.from, in.EndToEndID)]; ok {
+				r.errs = append(r.errs, "end_to_end_id was already used in a previous file")
+			}
+		}
+		key := fmt.Sprintf("%d|%d|%d|%s|%s", r.from, r.to, r.amount, strings.ToUpper(in.Currency), in.Remittance)
+		if first, ok := seenLine[key]; ok {
+			r.errs = append(r.errs, fmt.Sprintf("duplicate of line %d", first))
+		} else {
+			seenLine[key] = in.LineNo
+		}
+
+		l := in.line(r.errs)
+		l.FromAccountID, l.ToAccountID, l.AmountCents = r.from, r.to, r.amount
+		lines = append(lines, l)
+	}
+	return lines, nil
+}
@@ -21,6 +21,26 @@ type Config struct {
 	RedisPort     string
 	RedisPassword string
 	RedisDB       string
+
+	// Transfers above this amount, in the default currency's cents, wait
+	// for a second admin
+	ApprovalThresholdCents string
+
+	// Withholding tax on capitalized interest, in basis points
+	InterestWithholdingBps string
+
+	// Default annual rate charged on overdrawn balances, in basis points
+	OverdraftRateBps string
+
+	// Events of an account between two automatic snapshots, 0 to disable
+	AccountSnapshotEvery string
+
+	// Event bus mode: "async" queues events per subscriber with retries and
+	// dead letters, "sync" calls handlers inside Publish
+	EventBusMode string
+
+	// Handler attempts of the async bus before an event is dead-lettered
+	EventMaxAttempts string
 }
 
 func LoadConfig() *Config {
@@ -42,6 +62,13 @@ func LoadConfig() *Config {
 		RedisPort:     getEnvWithDefault("REDIS_PORT", "6379"),
 		RedisPassword: getEnvWithDefault("REDIS_PASSWORD", ""),
 		RedisDB:       getEnvWithDefault("REDIS_DB", "0"),
+
+		ApprovalThresholdCents: getEnvWithDefault("APPROVAL_THRESHOLD_CENTS", "10000000"),
+		InterestWithholdingBps: getEnvWithDefault("INTEREST_WITHHOLDING_BPS", "1500"),
+		OverdraftRateBps:       getEnvWithDefault("OVERDRAFT_RATE_BPS", "3600"),
+		AccountSnapshotEvery:   getEnvWithDefault("ACCOUNT_SNAPSHOT_EVERY", "100"),
+		EventBusMode:           getEnvWithDefault("EVENT_BUS_MODE", "async"),
+		EventMaxAttempts:       getEnvWithDefault("EVENT_MAX_ATTEMPTS", "5"),
 	}
 
 	// Validate critical configurations
@@ -89,6 +116,30 @@ func (c *Config) Validate() error {
 		println("⚠️ APP_PORT geçersiz:", c.AppPort)
 	}
 
+	if _, err := strconv.ParseInt(c.ApprovalThresholdCents, 10, 64); err != nil {
+		println("⚠️ APPROVAL_THRESHOLD_CENTS geçersiz:", c.ApprovalThresholdCents)
+	}
+
+	if bps, err := strconv.ParseInt(c.InterestWithholdingBps, 10, 64); err != nil || bps < 0 || bps > 10000 {
+		println("⚠️ INTEREST_WITHHOLDING_BPS geçersiz:", c.InterestWithholdingBps)
+	}
+
+	if bps, err := strconv.ParseInt(c.OverdraftRateBps, 10, 64); err != nil || bps < 0 {
+		println("⚠️ OVERDRAFT_RATE_BPS geçersiz:", c.OverdraftRateBps)
+	}
+
+	if n, err := strconv.ParseInt(c.AccountSnapshotEvery, 10, 64); err != nil || n < 0 {
+		println("⚠️ ACCOUNT_SNAPSHOT_EVERY geçersiz:", c.AccountSnapshotEvery)
+	}
+
+	if c.EventBusMode != "async" && c.EventBusMode != "sync" {
+		println("⚠️ EVENT_BUS_MODE geçersiz:", c.EventBusMode)
+	}
+
+	if n, err := strconv.Atoi(c.EventMaxAttempts); err != nil || n <= 0 {
+		println("⚠️ EVENT_MAX_ATTEMPTS geçersiz:", c.EventMaxAttempts)
+	}
+
 	println("✅ Konfigürasyon doğrulandı")
 	return nil
 }
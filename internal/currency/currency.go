@@ -2,10 +2,17 @@ package currency
 
 import (
 	"fmt"
+	"math"
 	"sync"
 	"time"
 )
 
+// DefaultCurrency is used whenever a request does not name a currency
+const DefaultCurrency = "TRY"
+
+// DefaultSpreadBps is the FX margin applied to cross-currency transfers
+const DefaultSpreadBps = 50
+
 // Currency represents a currency with its code and exchange rates
 type Currency struct {
 	Code         string    `json:"code" gorm:"primaryKey;size:3"`
@@ -24,24 +31,52 @@ type ExchangeRate struct {
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+// Quote is a priced cross-currency conversion of an amount in cents
+type Quote struct {
+	FromCurrency    string  `json:"from_currency"`
+	ToCurrency      string  `json:"to_currency"`
+	MidRate         float64 `json:"mid_rate"`
+	SpreadBps       int     `json:"spread_bps"`
+	AppliedRate     float64 `json:"applied_rate"`
+	FromAmountCents int64   `json:"from_amount_cents"`
+	ToAmountCents   int64   `json:"to_amount_cents"`
+}
+
 // CurrencyService manages currency operations
 type CurrencyService struct {
-	rates map[string]float64
-	mutex sync.RWMutex
+	rates     map[string]float64
+	spreadBps int
+	mutex     sync.RWMutex
 }
 
+var (
+	defaultService     *CurrencyService
+	defaultServiceOnce sync.Once
+)
+
 // NewCurrencyService creates a new currency service
 func NewCurrencyService() *CurrencyService {
 	println("💱 Currency service oluşturuluyor...")
 
 	service := &CurrencyService{
-		rates: make(map[string]float64),
+		rates:     make(map[string]float64),
+		spreadBps: DefaultSpreadBps,
 	}
 
 	println("✅ Currency service oluşturuldu")
 	return service
 }
 
+// Default returns the process-wide currency service shared by the currency
+// API and the transaction service, seeded with the initial rates.
+func Default() *CurrencyService {
+	defaultServiceOnce.Do(func() {
+		defaultService = NewCurrencyService()
+		_ = defaultService.UpdateRates()
+	})
+	return defaultService
+}
+
 // Convert converts an amount from one currency to another
 func (cs *CurrencyService) Convert(amount float64, fromCurrency, toCurrency string) (float64, error) {
 	if fromCurrency == toCurrency {
@@ -107,3 +142,57 @@ func (cs *CurrencyService) UpdateRates() error {
 func (cs *CurrencyService) GetSupportedCurrencies() []string {
 	return []string{"USD", "EUR", "TRY"}
 }
+
+// IsSupported reports whether code is a supported ISO currency
+func (cs *CurrencyService) IsSupported(code string) bool {
+	for _, c := range cs.GetSupportedCurrencies() {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSpreadBps sets the FX spread in basis points
+func (cs *CurrencyService) SetSpreadBps(bps int) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	cs.spreadBps = bps
+}
+
+// Quote prices the conversion of amountCents, applying the spread in the
+// bank's favour. The converted amount is rounded to the nearest cent.
+func (cs *CurrencyService) Quote(amountCents int64, fromCurrency, toCurrency string) (Quote, error) {
+	if amountCents <= 0 {
+		return Quote{}, fmt.Errorf("amount must be positive")
+	}
+
+	mid, err := cs.GetExchangeRate(fromCurrency, toCurrency)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	cs.mutex.RLock()
+	spread := cs.spreadBps
+	cs.mutex.RUnlock()
+
+	if fromCurrency == toCurrency {
+		spread = 0
+	}
+
+	applied := mid * (1 - float64(spread)/10000.0)
+	toAmount := int64(math.Round(float64(amountCents) * applied))
+	if toAmount <= 0 {
+		return Quote{}, fmt.Errorf("converted amount is too small")
+	}
+
+	return Quote{
+		FromCurrency:    fromCurrency,
+		ToCurrency:      toCurrency,
+		MidRate:         mid,
+		SpreadBps:       spread,
+		AppliedRate:     applied,
+		FromAmountCents: amountCents,
+		ToAmountCents:   toAmount,
+	}, nil
+}
@@ -1,6 +1,10 @@
 package currency
 
 import (
+	"bankapi/internal/approval"
+	"bankapi/internal/user"
+	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -12,7 +16,7 @@ type Handler struct {
 
 func NewHandler() *Handler {
 	return &Handler{
-		service: NewCurrencyService(),
+		service: Default(),
 	}
 }
 
@@ -31,12 +35,6 @@ func (h *Handler) ConvertCurrency(c *gin.Context) {
 		return
 	}
 
-	// Update rates from external source
-	if err := h.service.UpdateRates(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update exchange rates"})
-		return
-	}
-
 	convertedAmount, err := h.service.Convert(req.Amount, req.FromCurrency, req.ToCurrency)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -56,12 +54,6 @@ func (h *Handler) GetExchangeRate(c *gin.Context) {
 	fromCurrency := c.Param("from")
 	toCurrency := c.Param("to")
 
-	// Update rates from external source
-	if err := h.service.UpdateRates(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update exchange rates"})
-		return
-	}
-
 	rate, err := h.service.GetExchangeRate(fromCurrency, toCurrency)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -83,7 +75,15 @@ func (h *Handler) GetSupportedCurrencies(c *gin.Context) {
 	})
 }
 
-// UpdateExchangeRate updates the exchange rate between two currencies
+// RateUpdatePayload is the parked body of an exchange rate change
+type RateUpdatePayload struct {
+	FromCurrency string  `json:"from_currency"`
+	ToCurrency   string  `json:"to_currency"`
+	Rate         float64 `json:"rate"`
+}
+
+// UpdateExchangeRate requests a change of the exchange rate between two
+// currencies. The rate only changes once a second admin approves it.
 func (h *Handler) UpdateExchangeRate(c *gin.Context) {
 	fromCurrency := c.Param("from")
 	toCurrency := c.Param("to")
@@ -97,12 +97,32 @@ func (h *Handler) UpdateExchangeRate(c *gin.Context) {
 		return
 	}
 
-	h.service.SetExchangeRate(fromCurrency, toCurrency, req.Rate)
+	requester, ok := user.Current(c)
+	if !ok || !requester.IsAdmin() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Erişim reddedildi"})
+		return
+	}
+
+	payload := RateUpdatePayload{FromCurrency: fromCurrency, ToCurrency: toCurrency, Rate: req.Rate}
+	pending, err := approval.Submit(approval.ActionRateUpdate, fromCurrency+"/"+toCurrency, payload, requester.ID,
+		fmt.Sprintf("%s/%s rate -> %g", fromCurrency, toCurrency, req.Rate))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":       "Exchange rate updated successfully",
-		"from_currency": fromCurrency,
-		"to_currency":   toCurrency,
-		"rate":          req.Rate,
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":  "Exchange rate update is waiting for approval",
+		"approval": pending,
 	})
 }
+
+// applyRateUpdate is the approval executor for exchange rate changes
+func (h *Handler) applyRateUpdate(payload []byte) (interface{}, error) {
+	var p RateUpdatePayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, err
+	}
+	h.service.SetExchangeRate(p.FromCurrency, p.ToCurrency, p.Rate)
+	return p, nil
+}
@@ -1,6 +1,8 @@
 package currency
 
 import (
+	"bankapi/internal/approval"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -13,6 +15,7 @@ func RegisterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
 	}
 
 	handler := NewHandler()
+	approval.RegisterExecutor(approval.ActionRateUpdate, handler.applyRateUpdate)
 
 	curr.POST("/convert", handler.ConvertCurrency)
 	curr.GET("/rates/:from/:to", handler.GetExchangeRate)
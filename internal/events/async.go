@@ -0,0 +1,275 @@
+package events
+
+import (
+	"bankapi/internal/logger"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrBusClosed          = errors.New("event bus is closed")
+	ErrSubscriberNotFound = errors.New("subscriber not found")
+)
+
+// AsyncOptions tune an AsyncEventBus
+type AsyncOptions struct {
+	QueueSize   int           // events buffered per subscriber
+	MaxAttempts int           // handler attempts before an event is dead-lettered
+	BaseBackoff time.Duration // wait after the first failure, doubled after each
+	MaxBackoff  time.Duration
+}
+
+// DefaultAsyncOptions are used for zero fields of AsyncOptions
+var DefaultAsyncOptions = AsyncOptions{
+	QueueSize:   1000,
+	MaxAttempts: 5,
+	BaseBackoff: 500 * time.Millisecond,
+	MaxBackoff:  30 * time.Second,
+}
+
+// AsyncEventBus implements EventBus with one queue and goroutine per
+// subscriber. Publish only enqueues, so neither the publisher nor the other
+// subscribers wait for a slow or failing handler. A handler that keeps
+// failing is retried with exponential backoff and the event is then stored
+// as a dead letter, as is an event whose subscriber queue is full.
+type AsyncEventBus struct {
+	opts        AsyncOptions
+	subscribers map[string][]*subscriber
+	byName      map[string]*subscriber
+	mutex       sync.RWMutex
+	wg          sync.WaitGroup
+	closed      bool
+}
+
+type subscriber struct {
+	name      string
+	eventType string
+	handler   EventHandler
+	queue     chan delivery
+}
+
+// delivery is an event queued for one subscriber. tracker is set when the
+// publisher wants to know once every subscriber is done with the event.
+type delivery struct {
+	event   Event
+	tracker *ackTracker
+}
+
+// ackTracker calls ack once every subscriber has handled the event or has it
+// stored as a dead letter. If a dead letter could not be stored ack is never
+// called, so the publisher delivers the event again.
+type ackTracker struct {
+	remaining atomic.Int32
+	lost      atomic.Bool
+	ack       func()
+}
+
+func (t *ackTracker) done(settled bool) {
+	if t == nil {
+		return
+	}
+	if !settled {
+		t.lost.Store(true)
+	}
+	if t.remaining.Add(-1) == 0 && !t.lost.Load() {
+		t.ack()
+	}
+}
+
+// NewAsyncEventBus creates an asynchronous event bus
+func NewAsyncEventBus(opts AsyncOptions) *AsyncEventBus {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultAsyncOptions.QueueSize
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultAsyncOptions.MaxAttempts
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = DefaultAsyncOptions.BaseBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultAsyncOptions.MaxBackoff
+	}
+	return &AsyncEventBus{
+		opts:        opts,
+		subscribers: make(map[string][]*subscriber),
+		byName:      make(map[string]*subscriber),
+	}
+}
+
+// Publish queues the event for every subscriber of its type
+func (eb *AsyncEventBus) Publish(event Event) error {
+	return eb.publish(event, nil)
+}
+
+// PublishAcked queues the event like Publish and calls ack once every
+// subscriber has handled it or it is stored as their dead letter. ack runs
+// on a subscriber goroutine, or before PublishAcked returns when there is
+// nothing to wait for; it is not called if a dead letter could not be
+// stored.
+func (eb *AsyncEventBus) PublishAcked(event Event, ack func()) error {
+	return eb.publish(event, &ackTracker{ack: ack})
+}
+
+func (eb *AsyncEventBus) publish(event Event, tracker *ackTracker) error {
+	eb.mutex.RLock()
+	defer eb.mutex.RUnlock()
+
+	if eb.closed {
+		return ErrBusClosed
+	}
+	subscribers := eb.subscribers[event.Type]
+	if tracker != nil {
+		if len(subscribers) == 0 {
+			tracker.ack()
+			return nil
+		}
+		tracker.remaining.Store(int32(len(subscribers)))
+	}
+	for _, s := range subscribers {
+		select {
+		case s.queue <- delivery{event: event, tracker: tracker}:
+		default:
+			println("⚠️ Abone kuyruğu dolu, event dead-letter'a alınıyor:", s.name, event.ID)
+			tracker.done(eb.deadLetter(s, event, 0, fmt.Errorf("subscriber queue full")))
+		}
+	}
+	return nil
+}
+
+// Subscribe adds a handler for a specific event type. The subscriber is named
+// after the type and its position, e.g. "transaction.completed#1"; use
+// SubscribeNamed for a name that survives reordering.
+func (eb *AsyncEventBus) Subscribe(eventType string, handler EventHandler) error {
+	eb.mutex.RLock()
+	n := len(eb.subscribers[eventType])
+	eb.mutex.RUnlock()
+	return eb.SubscribeNamed(eventType, fmt.Sprintf("%s#%d", eventType, n+1), handler)
+}
+
+// SubscribeNamed adds a handler under a unique name. Dead letters record the
+// name so they can be replayed to the same handler.
+func (eb *AsyncEventBus) SubscribeNamed(eventType, name string, handler EventHandler) error {
+	println("📝 Asenkron event handler kaydediliyor, tip:", eventType, "ad:", name)
+
+	if eventType == "" {
+		return fmt.Errorf("event type cannot be empty")
+	}
+	if handler == nil {
+		return fmt.Errorf("handler cannot be nil")
+	}
+
+	eb.mutex.Lock()
+	defer eb.mutex.Unlock()
+
+	if eb.closed {
+		return ErrBusClosed
+	}
+	if _, exists := eb.byName[name]; exists {
+		return fmt.Errorf("subscriber already registered: %s", name)
+	}
+
+	s := &subscriber{name: name, eventType: eventType, handler: handler, queue: make(chan delivery, eb.opts.QueueSize)}
+	eb.subscribers[eventType] = append(eb.subscribers[eventType], s)
+	eb.byName[name] = s
+
+	eb.wg.Add(1)
+	go eb.run(s)
+	return nil
+}
+
+// Close stops accepting events and waits until every queued event has been
+// handled or dead-lettered
+func (eb *AsyncEventBus) Close() {
+	eb.mutex.Lock()
+	if eb.closed {
+		eb.mutex.Unlock()
+		return
+	}
+	eb.closed = true
+	for _, s := range eb.byName {
+		close(s.queue)
+	}
+	eb.mutex.Unlock()
+
+	eb.wg.Wait()
+	println("✅ Asenkron event bus kapatıldı")
+}
+
+// run delivers the subscriber's events one at a time, in publish order
+func (eb *AsyncEventBus) run(s *subscriber) {
+	defer eb.wg.Done()
+	for d := range s.queue {
+		attempts, err := eb.deliver(s, d.event)
+		settled := true
+		if err != nil {
+			settled = eb.deadLetter(s, d.event, attempts, err)
+		}
+		d.tracker.done(settled)
+	}
+}
+
+// deliver calls the handler until it succeeds or runs out of attempts
+func (eb *AsyncEventBus) deliver(s *subscriber, event Event) (int, error) {
+	backoff := eb.opts.BaseBackoff
+	var err error
+	for attempt := 1; attempt <= eb.opts.MaxAttempts; attempt++ {
+		if err = call(s.handler, event); err == nil {
+			return attempt, nil
+		}
+		println("❌ Handler hatası, abone:", s.name, "deneme:", attempt, "hata:", err.Error())
+		if attempt == eb.opts.MaxAttempts {
+			return attempt, err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > eb.opts.MaxBackoff {
+			backoff = eb.opts.MaxBackoff
+		}
+	}
+	return eb.opts.MaxAttempts, err
+}
+
+// call runs the handler, turning a panic into an error
+func call(handler EventHandler, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return handler(event)
+}
+
+// deadLetter stores event as a dead letter of s and reports whether it was
+// stored
+func (eb *AsyncEventBus) deadLetter(s *subscriber, event Event, attempts int, cause error) bool {
+	logger.Error("Event dead-lettered", cause, map[string]interface{}{
+		"event_id":   event.ID,
+		"event_type": event.Type,
+		"subscriber": s.name,
+		"attempts":   attempts,
+	})
+	if err := SaveDeadLetter(s.name, event, attempts, cause); err != nil {
+		logger.Error("Failed to store dead letter", err, map[string]interface{}{
+			"event_id":   event.ID,
+			"subscriber": s.name,
+		})
+		return false
+	}
+	return true
+}
+
+// subscriber looks up a subscriber by name. A nil bus, as when the sync bus
+// is in use, has none.
+func (eb *AsyncEventBus) subscriber(name string) (*subscriber, bool) {
+	if eb == nil {
+		return nil, false
+	}
+	eb.mutex.RLock()
+	defer eb.mutex.RUnlock()
+	s, ok := eb.byName[name]
+	return s, ok
+}
@@ -0,0 +1,180 @@
+package events
+
+import (
+	"bankapi/internal/audit"
+	"bankapi/internal/db"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DeadLetterStatus string
+
+const (
+	DeadLetterStatusPending   DeadLetterStatus = "pending"
+	DeadLetterStatusReplayed  DeadLetterStatus = "replayed"
+	DeadLetterStatusDiscarded DeadLetterStatus = "discarded"
+)
+
+var (
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+	ErrDeadLetterResolved = errors.New("dead letter is already resolved")
+	// ErrReplayFailed wraps the handler error of a replay that failed again
+	ErrReplayFailed = errors.New("dead letter replay failed")
+)
+
+// DeadLetter is an event a subscriber of the async bus could not handle.
+// It stays pending until an admin replays it to the same subscriber or
+// discards it.
+type DeadLetter struct {
+	ID         uint             `json:"id" gorm:"primaryKey"`
+	EventID    string           `json:"event_id" gorm:"size:64;index;not null"`
+	EventType  string           `json:"event_type" gorm:"size:100;index;not null"`
+	Subscriber string           `json:"subscriber" gorm:"size:150;index;not null"`
+	Payload    string           `json:"payload" gorm:"type:text;not null"` // the serialized Event
+	Attempts   int              `json:"attempts" gorm:"not null;default:0"`
+	LastError  string           `json:"last_error" gorm:"type:text"`
+	Status     DeadLetterStatus `json:"status" gorm:"size:20;not null;index"`
+	ResolvedAt *time.Time       `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+}
+
+func (DeadLetter) TableName() string { return "event_dead_letters" }
+
+// SaveDeadLetter stores event as a pending dead letter of subscriber
+func SaveDeadLetter(subscriber string, event Event, attempts int, cause error) error {
+	if db.DB == nil {
+		return fmt.Errorf("database is not available")
+	}
+	payload, err := event.Serialize()
+	if err != nil {
+		return fmt.Errorf("failed to serialize event %s: %w", event.ID, err)
+	}
+	dl := DeadLetter{
+		EventID:    event.ID,
+		EventType:  event.Type,
+		Subscriber: subscriber,
+		Payload:    string(payload),
+		Attempts:   attempts,
+		LastError:  cause.Error(),
+		Status:     DeadLetterStatusPending,
+	}
+	if err := db.DB.Create(&dl).Error; err != nil {
+		return fmt.Errorf("failed to store dead letter: %w", err)
+	}
+	println("📭 Event dead-letter'a alındı, ID:", dl.ID, "abone:", subscriber)
+	return nil
+}
+
+// ListDeadLetters returns the newest dead letters, optionally only those
+// with status
+func ListDeadLetters(status DeadLetterStatus, limit int) ([]DeadLetter, error) {
+	q := db.DB.Order("created_at DESC, id DESC").Limit(limit)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	var letters []DeadLetter
+	if err := q.Find(&letters).Error; err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	return letters, nil
+}
+
+func GetDeadLetter(id uint) (*DeadLetter, error) {
+	var dl DeadLetter
+	if err := db.DB.First(&dl, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeadLetterNotFound
+		}
+		return nil, fmt.Errorf("failed to load dead letter: %w", err)
+	}
+	return &dl, nil
+}
+
+// DiscardDeadLetter resolves a pending dead letter without handling it
+func DiscardDeadLetter(id uint) (*DeadLetter, error) {
+	var dl DeadLetter
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockPending(tx, id, &dl); err != nil {
+			return err
+		}
+		now := time.Now()
+		dl.Status = DeadLetterStatusDiscarded
+		dl.ResolvedAt = &now
+		if err := tx.Save(&dl).Error; err != nil {
+			return fmt.Errorf("failed to discard dead letter: %w", err)
+		}
+		return audit.LogTx(tx, "dead_letter", strconv.FormatUint(uint64(id), 10), "discard", fmt.Sprintf("event=%s subscriber=%s", dl.EventID, dl.Subscriber))
+	})
+	if err != nil {
+		return nil, err
+	}
+	println("🗑️ Dead letter atıldı, ID:", id)
+	return &dl, nil
+}
+
+// Replay hands a pending dead letter to its subscriber once more and marks
+// it replayed when the handler succeeds. A failing handler counts as another
+// attempt; the letter stays pending and ErrReplayFailed is returned with it.
+// The row is locked while the handler runs, so a letter is never replayed
+// twice at once.
+func (eb *AsyncEventBus) Replay(id uint) (*DeadLetter, error) {
+	var dl DeadLetter
+	var cause error
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockPending(tx, id, &dl); err != nil {
+			return err
+		}
+		s, ok := eb.subscriber(dl.Subscriber)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrSubscriberNotFound, dl.Subscriber)
+		}
+		event, err := DeserializeEvent([]byte(dl.Payload))
+		if err != nil {
+			return fmt.Errorf("failed to decode dead letter %d: %w", id, err)
+		}
+
+		cause = call(s.handler, event)
+		dl.Attempts++
+		outcome := "ok"
+		if cause != nil {
+			dl.LastError = cause.Error()
+			outcome = cause.Error()
+		} else {
+			now := time.Now()
+			dl.Status = DeadLetterStatusReplayed
+			dl.ResolvedAt = &now
+		}
+		if err := tx.Save(&dl).Error; err != nil {
+			return fmt.Errorf("failed to update dead letter: %w", err)
+		}
+		return audit.LogTx(tx, "dead_letter", strconv.FormatUint(uint64(id), 10), "replay", fmt.Sprintf("event=%s subscriber=%s result=%s", dl.EventID, dl.Subscriber, outcome))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if cause != nil {
+		println("❌ Dead letter yeniden işlenemedi, ID:", id, "hata:", cause.Error())
+		return &dl, fmt.Errorf("%w: %v", ErrReplayFailed, cause)
+	}
+	println("✅ Dead letter yeniden işlendi, ID:", id)
+	return &dl, nil
+}
+
+func lockPending(tx *gorm.DB, id uint, dl *DeadLetter) error {
+	if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).First(dl, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrDeadLetterNotFound
+		}
+		return fmt.Errorf("failed to load dead letter: %w", err)
+	}
+	if dl.Status != DeadLetterStatusPending {
+		return ErrDeadLetterResolved
+	}
+	return nil
+}
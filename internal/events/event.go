@@ -1,6 +1,7 @@
 package events
 
 import (
+	"crypto/rand"
 	"encoding/json"
 	"time"
 )
@@ -11,6 +12,7 @@ type Event struct {
 	Type        string                 `json:"type"`
 	AggregateID string                 `json:"aggregate_id"`
 	Version     int                    `json:"version"`
+	Position    int64                  `json:"position,omitempty"` // global position once stored
 	Data        map[string]interface{} `json:"data"`
 	Metadata    map[string]interface{} `json:"metadata"`
 	Timestamp   time.Time              `json:"timestamp"`
@@ -63,8 +65,11 @@ func randomString(length int) string {
 	println("🎲 Random string oluşturuluyor, uzunluk:", length)
 	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 	b := make([]byte, length)
+	if _, err := rand.Read(b); err != nil {
+		panic(err) // crypto/rand never fails on supported platforms
+	}
 	for i := range b {
-		b[i] = charset[time.Now().UnixNano()%int64(len(charset))]
+		b[i] = charset[int(b[i])%len(charset)]
 	}
 	result := string(b)
 	println("🎲 Random string oluşturuldu:", result)
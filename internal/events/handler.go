@@ -0,0 +1,109 @@
+package events
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	bus *AsyncEventBus
+}
+
+func NewHandler(bus *AsyncEventBus) *Handler {
+	return &Handler{bus: bus}
+}
+
+// ListDeadLetters returns dead letters, newest first, optionally filtered by
+// ?status=pending|replayed|discarded
+func (h *Handler) ListDeadLetters(c *gin.Context) {
+	limit := 100
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit geçersiz"})
+			return
+		}
+		limit = n
+	}
+	status := DeadLetterStatus(c.Query("status"))
+	switch status {
+	case "", DeadLetterStatusPending, DeadLetterStatusReplayed, DeadLetterStatusDiscarded:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status geçersiz"})
+		return
+	}
+	letters, err := ListDeadLetters(status, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "dead letter kayıtları getirilemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, letters)
+}
+
+func (h *Handler) GetDeadLetter(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	dl, err := GetDeadLetter(id)
+	if err != nil {
+		respondError(c, dl, err)
+		return
+	}
+	c.JSON(http.StatusOK, dl)
+}
+
+// ReplayDeadLetter hands a pending dead letter to its subscriber again
+func (h *Handler) ReplayDeadLetter(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	dl, err := h.bus.Replay(id)
+	if err != nil {
+		respondError(c, dl, err)
+		return
+	}
+	c.JSON(http.StatusOK, dl)
+}
+
+// DiscardDeadLetter resolves a pending dead letter without handling it
+func (h *Handler) DiscardDeadLetter(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	dl, err := DiscardDeadLetter(id)
+	if err != nil {
+		respondError(c, dl, err)
+		return
+	}
+	c.JSON(http.StatusOK, dl)
+}
+
+func parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dead letter ID geçersiz"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondError(c *gin.Context, dl *DeadLetter, err error) {
+	switch {
+	case errors.Is(err, ErrDeadLetterNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "dead letter bulunamadı"})
+	case errors.Is(err, ErrDeadLetterResolved):
+		c.JSON(http.StatusConflict, gin.H{"error": "dead letter zaten sonuçlandırılmış"})
+	case errors.Is(err, ErrSubscriberNotFound):
+		c.JSON(http.StatusConflict, gin.H{"error": "event'in abonesi artık kayıtlı değil"})
+	case errors.Is(err, ErrReplayFailed):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "event yeniden işlenemedi", "dead_letter": dl})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "dead letter işlenemedi"})
+	}
+}
@@ -0,0 +1,214 @@
+package events
+
+import (
+	"bankapi/internal/db"
+	"bankapi/internal/logger"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxStatusPending   OutboxStatus = "pending"
+	OutboxStatusPublished OutboxStatus = "published"
+	// OutboxStatusFailed is a message that ran out of attempts
+	OutboxStatusFailed OutboxStatus = "failed"
+
+	// DefaultOutboxMaxAttempts is how often the relay tries a message
+	DefaultOutboxMaxAttempts = 10
+	// maxOutboxBackoff caps the wait between two attempts
+	maxOutboxBackoff = 5 * time.Minute
+	// outboxAckTimeout is how long a message handed to an AckingBus waits
+	// for its ack before it is delivered again
+	outboxAckTimeout = 5 * time.Minute
+)
+
+// AckingBus is an EventBus that confirms when a published event has been
+// handled, rather than when it was accepted
+type AckingBus interface {
+	EventBus
+	PublishAcked(event Event, ack func()) error
+}
+
+// OutboxMessage is an event waiting to be published. It is written in the
+// same database transaction as the change it describes, so an event exists
+// if and only if the change was committed.
+type OutboxMessage struct {
+	ID            uint         `json:"id" gorm:"primaryKey"`
+	EventID       string       `json:"event_id" gorm:"size:64;uniqueIndex;not null"`
+	Type          string       `json:"type" gorm:"size:100;index;not null"`
+	AggregateID   string       `json:"aggregate_id" gorm:"size:64;index;not null"`
+	Payload       string       `json:"payload" gorm:"type:text;not null"` // the serialized Event
+	Status        OutboxStatus `json:"status" gorm:"size:20;not null;index:idx_outbox_due,priority:1"`
+	Attempts      int          `json:"attempts" gorm:"not null;default:0"`
+	LastError     string       `json:"last_error,omitempty" gorm:"type:text"`
+	NextAttemptAt time.Time    `json:"next_attempt_at" gorm:"index:idx_outbox_due,priority:2"`
+	PublishedAt   *time.Time   `json:"published_at,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+func (OutboxMessage) TableName() string { return "event_outbox" }
+
+// EnqueueTx writes events to the outbox inside tx. They are published by the
+// relay once tx commits.
+func EnqueueTx(tx *gorm.DB, events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	now := time.Now()
+	rows := make([]OutboxMessage, 0, len(events))
+	for _, e := range events {
+		payload, err := e.Serialize()
+		if err != nil {
+			return fmt.Errorf("failed to serialize event %s: %w", e.Type, err)
+		}
+		rows = append(rows, OutboxMessage{
+			EventID:       e.ID,
+			Type:          e.Type,
+			AggregateID:   e.AggregateID,
+			Payload:       string(payload),
+			Status:        OutboxStatusPending,
+			NextAttemptAt: now,
+		})
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return fmt.Errorf("failed to write outbox: %w", err)
+	}
+	return nil
+}
+
+// OutboxRelay publishes outbox messages to an EventBus. A message is marked
+// published only after the bus accepted it, so delivery is at least once and
+// subscribers must tolerate duplicates. With an AckingBus accepting is not
+// enough: the message stays pending until the bus acks it and is delivered
+// again if no ack arrives within outboxAckTimeout, e.g. after a crash.
+// Failed messages are retried with exponential backoff until MaxAttempts.
+type OutboxRelay struct {
+	bus         EventBus
+	BatchSize   int
+	MaxAttempts int
+	running     atomic.Bool
+}
+
+// NewOutboxRelay creates a relay publishing to bus
+func NewOutboxRelay(bus EventBus) *OutboxRelay {
+	return &OutboxRelay{bus: bus, BatchSize: 100, MaxAttempts: DefaultOutboxMaxAttempts}
+}
+
+// RelayOnce publishes the messages that are due and returns how many were
+// published. Rows are claimed with SKIP LOCKED so several replicas can relay
+// at once without publishing the same message concurrently.
+func (r *OutboxRelay) RelayOnce() (int, error) {
+	if !r.running.CompareAndSwap(false, true) {
+		return 0, nil
+	}
+	defer r.running.Store(false)
+
+	acking, _ := r.bus.(AckingBus)
+	published, dispatched, failed := 0, 0, 0
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var due []OutboxMessage
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked}).
+			Where("status = ? AND next_attempt_at <= ?", OutboxStatusPending, time.Now()).
+			Order("id").
+			Limit(r.BatchSize).
+			Find(&due).Error; err != nil {
+			return fmt.Errorf("failed to load outbox: %w", err)
+		}
+
+		for i := range due {
+			m := &due[i]
+			if acking != nil {
+				if err := r.dispatch(acking, m); err != nil {
+					failed++
+					r.retryLater(m, err)
+				} else {
+					dispatched++
+					m.NextAttemptAt = time.Now().Add(outboxAckTimeout)
+				}
+			} else if err := r.publish(m); err != nil {
+				failed++
+				r.retryLater(m, err)
+			} else {
+				published++
+				now := time.Now()
+				m.Status = OutboxStatusPublished
+				m.PublishedAt = &now
+				m.LastError = ""
+			}
+			m.Attempts++
+			if err := tx.Save(m).Error; err != nil {
+				return fmt.Errorf("failed to update outbox message %d: %w", m.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Outbox relay failed", err, nil)
+		return published, err
+	}
+	if published > 0 || dispatched > 0 || failed > 0 {
+		logger.Info("Outbox relayed", map[string]interface{}{
+			"published":  published,
+			"dispatched": dispatched,
+			"failed":     failed,
+		})
+	}
+	return published + dispatched, nil
+}
+
+func (r *OutboxRelay) publish(m *OutboxMessage) error {
+	event, err := DeserializeEvent([]byte(m.Payload))
+	if err != nil {
+		return fmt.Errorf("failed to decode event: %w", err)
+	}
+	return r.bus.Publish(event)
+}
+
+// dispatch hands m to an AckingBus; the ack marks it published
+func (r *OutboxRelay) dispatch(bus AckingBus, m *OutboxMessage) error {
+	event, err := DeserializeEvent([]byte(m.Payload))
+	if err != nil {
+		return fmt.Errorf("failed to decode event: %w", err)
+	}
+	id := m.ID
+	// The ack may come while RelayOnce still holds the row, even before
+	// PublishAcked returns, so it must not wait on this goroutine
+	return bus.PublishAcked(event, func() { go markPublished(id) })
+}
+
+// markPublished records the ack of a dispatched message. A message that was
+// delivered again meanwhile is simply marked once.
+func markPublished(id uint) {
+	now := time.Now()
+	if err := db.DB.Model(&OutboxMessage{}).
+		Where("id = ? AND status = ?", id, OutboxStatusPending).
+		Updates(map[string]interface{}{"status": OutboxStatusPublished, "published_at": now, "last_error": ""}).Error; err != nil {
+		logger.Error("Failed to mark outbox message published", err, map[string]interface{}{"outbox_id": id})
+	}
+}
+
+// retryLater schedules the next attempt of m, or gives up on it once it has
+// used all its attempts
+func (r *OutboxRelay) retryLater(m *OutboxMessage, cause error) {
+	m.LastError = cause.Error()
+	if m.Attempts+1 >= r.MaxAttempts {
+		m.Status = OutboxStatusFailed
+		logger.Error("Outbox message gave up", cause, map[string]interface{}{
+			"event_id": m.EventID,
+			"type":     m.Type,
+			"attempts": m.Attempts + 1,
+		})
+		return
+	}
+	backoff := time.Second << m.Attempts
+	if backoff > maxOutboxBackoff {
+		backoff = maxOutboxBackoff
+	}
+	m.NextAttemptAt = time.Now().Add(backoff)
+}
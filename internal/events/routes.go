@@ -0,0 +1,25 @@
+package events
+
+import (
+	"bankapi/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterDeadLetterRoutes registers the admin endpoints for the dead letters
+// of the async event bus
+func RegisterDeadLetterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc, bus *AsyncEventBus) {
+	r := router.Group("/api/v1/admin/dead-letters")
+
+	// Only use auth middleware if it's provided
+	if authMiddleware != nil {
+		r.Use(authMiddleware, middleware.RequireRoles("admin"))
+	}
+
+	handler := NewHandler(bus)
+
+	r.GET("", handler.ListDeadLetters)
+	r.GET("/:id", handler.GetDeadLetter)
+	r.POST("/:id/replay", handler.ReplayDeadLetter)
+	r.POST("/:id/discard", handler.DiscardDeadLetter)
+}
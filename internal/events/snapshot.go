@@ -0,0 +1,85 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AggregateSnapshot is the state of an aggregate after its events up to
+// Version. SchemaVersion identifies the aggregate logic that produced State;
+// a snapshot with another schema version is never loaded. EventAt is the time
+// of the last event it includes.
+type AggregateSnapshot struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	AggregateID   string    `json:"aggregate_id" gorm:"size:64;not null;uniqueIndex:idx_snapshot_aggregate_version"`
+	Version       int       `json:"version" gorm:"not null;uniqueIndex:idx_snapshot_aggregate_version"`
+	SchemaVersion int       `json:"schema_version" gorm:"not null;uniqueIndex:idx_snapshot_aggregate_version"`
+	State         string    `json:"state" gorm:"type:text;not null"`
+	EventAt       time.Time `json:"event_at" gorm:"not null"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (AggregateSnapshot) TableName() string { return "event_snapshots" }
+
+// SaveSnapshot stores state as the aggregate at version. Saving the same
+// version twice keeps the first snapshot.
+func (es *PostgresEventStore) SaveSnapshot(aggregateID string, version, schemaVersion int, eventAt time.Time, state interface{}) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot of %s: %w", aggregateID, err)
+	}
+	s := AggregateSnapshot{
+		AggregateID:   aggregateID,
+		Version:       version,
+		SchemaVersion: schemaVersion,
+		State:         string(raw),
+		EventAt:       eventAt,
+	}
+	if err := es.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&s).Error; err != nil {
+		return fmt.Errorf("failed to save snapshot of %s: %w", aggregateID, err)
+	}
+	return nil
+}
+
+// LatestSnapshot loads the newest snapshot of the aggregate taken with
+// schemaVersion into state and returns it. With until set only snapshots
+// whose last event is not after until are considered. It returns nil when
+// there is none.
+func (es *PostgresEventStore) LatestSnapshot(aggregateID string, schemaVersion int, until *time.Time, state interface{}) (*AggregateSnapshot, error) {
+	q := es.db.Where("aggregate_id = ? AND schema_version = ?", aggregateID, schemaVersion)
+	if until != nil {
+		q = q.Where("event_at <= ?", *until)
+	}
+	var s AggregateSnapshot
+	if err := q.Order("version DESC").First(&s).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load snapshot of %s: %w", aggregateID, err)
+	}
+	if err := json.Unmarshal([]byte(s.State), state); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot %d: %w", s.ID, err)
+	}
+	return &s, nil
+}
+
+// DeleteSnapshots invalidates the snapshots of an aggregate, or of every
+// aggregate when aggregateID is empty, and returns how many were removed.
+// Aggregates are then loaded from their events until new snapshots are
+// taken.
+func (es *PostgresEventStore) DeleteSnapshots(aggregateID string) (int64, error) {
+	q := es.db.Session(&gorm.Session{AllowGlobalUpdate: true})
+	if aggregateID != "" {
+		q = q.Where("aggregate_id = ?", aggregateID)
+	}
+	res := q.Delete(&AggregateSnapshot{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("failed to delete snapshots: %w", res.Error)
+	}
+	return res.RowsAffected, nil
+}
@@ -1,50 +1,347 @@
 package events
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultPageSize is used when a read asks for no limit
+	DefaultPageSize = 100
+	// MaxPageSize caps a single read
+	MaxPageSize = 1000
+
+	// appendLockKey guards the store as a whole: every append holds it
+	// shared until its transaction ends, so appends run in parallel, and
+	// whoever needs the store to stand still (a projection rebuild) takes
+	// it exclusively.
+	appendLockKey = 4203
+	// streamLockSeed seeds the 64-bit hash keying the lock that serialises
+	// the appends to one aggregate
+	streamLockSeed = 4202
 )
 
-// EventRecord represents the database record for events
+// ConcurrencyError is returned when an append expected the aggregate at a
+// different version, i.e. someone else appended to it meanwhile
+type ConcurrencyError struct {
+	AggregateID     string
+	ExpectedVersion int
+	ActualVersion   int
+}
+
+func (e *ConcurrencyError) Error() string {
+	return fmt.Sprintf("concurrency conflict on aggregate %s: expected version %d, actual %d", e.AggregateID, e.ExpectedVersion, e.ActualVersion)
+}
+
+// EventRecord represents the database record for events. ID is the event's
+// global position in the store.
 type EventRecord struct {
 	ID          uint      `json:"id" gorm:"primaryKey"`
 	EventID     string    `json:"event_id" gorm:"size:64;uniqueIndex;not null"`
 	Type        string    `json:"type" gorm:"size:100;index;not null"`
-	AggregateID string    `json:"aggregate_id" gorm:"size:64;index;not null"`
-	Version     int       `json:"version" gorm:"not null"`
+	AggregateID string    `json:"aggregate_id" gorm:"size:64;not null;uniqueIndex:idx_event_aggregate_version"`
+	Version     int       `json:"version" gorm:"not null;uniqueIndex:idx_event_aggregate_version"`
 	Data        string    `json:"data" gorm:"type:text"`
 	Metadata    string    `json:"metadata" gorm:"type:text"`
-	Timestamp   time.Time `json:"timestamp" gorm:"not null"`
+	Timestamp   time.Time `json:"timestamp" gorm:"not null;index"`
 }
 
 // PostgresEventStore implements EventStore using PostgreSQL
 type PostgresEventStore struct {
-	db interface{} // TODO: Replace with *gorm.DB
+	db *gorm.DB
 }
 
 // NewPostgresEventStore creates a new PostgreSQL event store
-func NewPostgresEventStore() *PostgresEventStore {
-	// TODO: Implement database connection
-	return &PostgresEventStore{db: nil}
+func NewPostgresEventStore(db *gorm.DB) *PostgresEventStore {
+	return &PostgresEventStore{db: db}
 }
 
-// Append adds events to the event store
+// Append adds events to the event store. The events must continue the
+// aggregate's stream: the first one carries the current version + 1 and
+// the rest follow without gaps. Otherwise nothing is stored and a
+// *ConcurrencyError is returned.
 func (es *PostgresEventStore) Append(aggregateID string, events ...Event) error {
-	// TODO: Implement database operations
-	fmt.Printf("Event stored in memory: %s for aggregate %s\n", events[0].Type, aggregateID)
+	return es.db.Transaction(func(tx *gorm.DB) error {
+		return es.AppendTx(tx, aggregateID, events...)
+	})
+}
+
+// AppendTx is Append inside tx, so events can be stored together with the
+// state they describe. Appends to different aggregates do not wait for each
+// other. Positions are taken at insert time, so a later position may commit
+// first; ReadAll only returns events below a watermark that no running
+// append can fall under.
+func (es *PostgresEventStore) AppendTx(tx *gorm.DB, aggregateID string, events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	println("🗃️ Event store'a yazılıyor, aggregate:", aggregateID, "event sayısı:", len(events))
+
+	// The transaction ID is assigned before any position is taken, so
+	// Watermark can tell from a snapshot which appends may still hold one
+	if err := tx.Exec("SELECT pg_current_xact_id(), pg_advisory_xact_lock_shared(?)", appendLockKey).Error; err != nil {
+		return fmt.Errorf("failed to lock event store: %w", err)
+	}
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtextextended(?, ?))", aggregateID, streamLockSeed).Error; err != nil {
+		return fmt.Errorf("failed to lock aggregate %s: %w", aggregateID, err)
+	}
+	current, err := es.versionTx(tx, aggregateID)
+	if err != nil {
+		return err
+	}
+	if events[0].Version != current+1 {
+		return &ConcurrencyError{AggregateID: aggregateID, ExpectedVersion: events[0].Version - 1, ActualVersion: current}
+	}
+
+	records := make([]EventRecord, 0, len(events))
+	for i, e := range events {
+		if e.Version != current+1+i {
+			return fmt.Errorf("event %d of aggregate %s has version %d, want %d", i, aggregateID, e.Version, current+1+i)
+		}
+		rec, err := toRecord(aggregateID, e)
+		if err != nil {
+			return err
+		}
+		records = append(records, rec)
+	}
+
+	if err := tx.Create(&records).Error; err != nil {
+		return fmt.Errorf("failed to append events: %w", err)
+	}
+	for i := range records {
+		events[i].Position = int64(records[i].ID)
+	}
+	return nil
+}
+
+// LockAppendsTx waits for the running appends to end and blocks new ones
+// until tx ends. Projections take it to rebuild from a store that does not
+// move underneath them.
+func LockAppendsTx(tx *gorm.DB) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", appendLockKey).Error; err != nil {
+		return fmt.Errorf("failed to lock event store: %w", err)
+	}
 	return nil
 }
 
+// watermarks carries the watermark from one read to the next. A candidate is
+// the newest position seen by a read together with the xmax of that read's
+// snapshot; it becomes the watermark once every transaction that was running
+// then has ended.
+var watermarks struct {
+	sync.Mutex
+	position  int64
+	candidate *watermarkCandidate
+}
+
+type watermarkCandidate struct {
+	position int64
+	xmax     int64
+}
+
+// Watermark returns the position up to which the store has no gaps left to
+// fill: every append that took a position at or below it has committed or
+// rolled back. It takes no locks, so it trails the newest events by the
+// appends that were running at the previous read. It must not be called
+// from a transaction that has written, whose own ID holds the snapshot back.
+func (es *PostgresEventStore) Watermark() (int64, error) {
+	var snap struct {
+		Position int64
+		Xmin     int64
+		Xmax     int64
+	}
+	if err := es.db.Raw(`SELECT COALESCE((SELECT MAX(id) FROM event_records), 0) AS position,
+		pg_snapshot_xmin(s)::text::bigint AS xmin, pg_snapshot_xmax(s)::text::bigint AS xmax
+		FROM pg_current_snapshot() AS s`).Scan(&snap).Error; err != nil {
+		return 0, fmt.Errorf("failed to read watermark: %w", err)
+	}
+
+	watermarks.Lock()
+	defer watermarks.Unlock()
+	// Appends take their transaction ID before their position, so an append
+	// holding a position at or below a candidate was running when the
+	// candidate was read, and has ended once the oldest running transaction
+	// is newer than anything that was running then
+	if c := watermarks.candidate; c != nil && snap.Xmin >= c.xmax {
+		watermarks.position = c.position
+		watermarks.candidate = nil
+	}
+	if watermarks.candidate == nil && snap.Position > watermarks.position {
+		if snap.Xmin == snap.Xmax {
+			watermarks.position = snap.Position // nothing is running
+		} else {
+			watermarks.candidate = &watermarkCandidate{position: snap.Position, xmax: snap.Xmax}
+		}
+	}
+	return watermarks.position, nil
+}
+
+// Version returns the aggregate's current version, 0 if it has no events
+func (es *PostgresEventStore) Version(aggregateID string) (int, error) {
+	return es.versionTx(es.db, aggregateID)
+}
+
+func (es *PostgresEventStore) versionTx(tx *gorm.DB, aggregateID string) (int, error) {
+	var version int
+	if err := tx.Model(&EventRecord{}).Where("aggregate_id = ?", aggregateID).
+		Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+		return 0, fmt.Errorf("failed to read version of aggregate %s: %w", aggregateID, err)
+	}
+	return version, nil
+}
+
 // GetEvents retrieves all events for a specific aggregate
 func (es *PostgresEventStore) GetEvents(aggregateID string) ([]Event, error) {
-	// TODO: Implement database operations
-	fmt.Printf("Getting events for aggregate: %s\n", aggregateID)
-	return []Event{}, nil
+	var records []EventRecord
+	if err := es.db.Where("aggregate_id = ?", aggregateID).Order("version").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to load events of aggregate %s: %w", aggregateID, err)
+	}
+	return fromRecords(records)
+}
+
+// GetEventsFrom returns up to limit events of the aggregate after
+// afterVersion, oldest first
+func (es *PostgresEventStore) GetEventsFrom(aggregateID string, afterVersion, limit int) ([]Event, error) {
+	var records []EventRecord
+	if err := es.db.Where("aggregate_id = ? AND version > ?", aggregateID, afterVersion).
+		Order("version").Limit(pageSize(limit)).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to load events of aggregate %s: %w", aggregateID, err)
+	}
+	return fromRecords(records)
 }
 
 // GetEventsByType retrieves all events of a specific type
 func (es *PostgresEventStore) GetEventsByType(eventType string) ([]Event, error) {
-	// TODO: Implement database operations
-	fmt.Printf("Getting events by type: %s\n", eventType)
-	return []Event{}, nil
+	var records []EventRecord
+	if err := es.db.Where("type = ?", eventType).Order("id").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to load %s events: %w", eventType, err)
+	}
+	return fromRecords(records)
+}
+
+// GetEventsByTypeFrom returns up to limit events of the type after the
+// global position afterPosition, oldest first
+func (es *PostgresEventStore) GetEventsByTypeFrom(eventType string, afterPosition int64, limit int) ([]Event, error) {
+	var records []EventRecord
+	if err := es.db.Where("type = ? AND id > ?", eventType, afterPosition).
+		Order("id").Limit(pageSize(limit)).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to load %s events: %w", eventType, err)
+	}
+	return fromRecords(records)
+}
+
+// ReadAll returns up to limit events of every aggregate after the global
+// position afterPosition, oldest first. Consumers tail the store by passing
+// the Position of the last event they handled. Only events up to the
+// Watermark are returned, so an event that commits late is never skipped.
+func (es *PostgresEventStore) ReadAll(afterPosition int64, limit int) ([]Event, error) {
+	watermark, err := es.Watermark()
+	if err != nil {
+		return nil, err
+	}
+	return es.readRange(afterPosition, watermark, limit)
+}
+
+// ReadAllLocked is ReadAll for a store on a transaction that holds
+// LockAppendsTx. No append is running, so every stored event is final and
+// no watermark is needed.
+func (es *PostgresEventStore) ReadAllLocked(afterPosition int64, limit int) ([]Event, error) {
+	last, err := es.LastPosition()
+	if err != nil {
+		return nil, err
+	}
+	return es.readRange(afterPosition, last, limit)
+}
+
+// readRange returns up to limit events after afterPosition and up to
+// throughPosition, oldest first
+func (es *PostgresEventStore) readRange(afterPosition, throughPosition int64, limit int) ([]Event, error) {
+	var records []EventRecord
+	if err := es.db.Where("id > ? AND id <= ?", afterPosition, throughPosition).Order("id").Limit(pageSize(limit)).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to read events: %w", err)
+	}
+	return fromRecords(records)
+}
+
+// LastPosition returns the position of the newest event, 0 if there is none
+func (es *PostgresEventStore) LastPosition() (int64, error) {
+	var position int64
+	if err := es.db.Model(&EventRecord{}).Select("COALESCE(MAX(id), 0)").Scan(&position).Error; err != nil {
+		return 0, fmt.Errorf("failed to read last position: %w", err)
+	}
+	return position, nil
+}
+
+func pageSize(limit int) int {
+	if limit <= 0 {
+		return DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		return MaxPageSize
+	}
+	return limit
+}
+
+func toRecord(aggregateID string, e Event) (EventRecord, error) {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return EventRecord{}, fmt.Errorf("failed to encode data of event %s: %w", e.Type, err)
+	}
+	metadata, err := json.Marshal(e.Metadata)
+	if err != nil {
+		return EventRecord{}, fmt.Errorf("failed to encode metadata of event %s: %w", e.Type, err)
+	}
+	if e.ID == "" {
+		e.ID = generateEventID()
+	}
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	return EventRecord{
+		EventID:     e.ID,
+		Type:        e.Type,
+		AggregateID: aggregateID,
+		Version:     e.Version,
+		Data:        string(data),
+		Metadata:    string(metadata),
+		Timestamp:   e.Timestamp,
+	}, nil
+}
+
+func fromRecords(records []EventRecord) ([]Event, error) {
+	events := make([]Event, 0, len(records))
+	for _, r := range records {
+		e := Event{
+			ID:          r.EventID,
+			Type:        r.Type,
+			AggregateID: r.AggregateID,
+			Version:     r.Version,
+			Position:    int64(r.ID),
+			Timestamp:   r.Timestamp,
+		}
+		if err := decodeMap(r.Data, &e.Data); err != nil {
+			return nil, fmt.Errorf("failed to decode data of event %s: %w", r.EventID, err)
+		}
+		if err := decodeMap(r.Metadata, &e.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode metadata of event %s: %w", r.EventID, err)
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// decodeMap decodes a JSON object keeping numbers as json.Number, so amounts
+// in cents survive the round trip exactly
+func decodeMap(raw string, out *map[string]interface{}) error {
+	if raw == "" {
+		return nil
+	}
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	return dec.Decode(out)
 }
@@ -0,0 +1,110 @@
+package fee
+
+import (
+	"fmt"
+	"time"
+)
+
+// Rule prices a fee for transactions of one type, optionally narrowed to a
+// role, a currency and an amount band. The fee is FixedCents plus PercentBps
+// of the amount, raised to MinFeeCents and capped at MaxFeeCents.
+type Rule struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	Name            string    `json:"name" gorm:"size:100;not null"`
+	TransactionType string    `json:"transaction_type" gorm:"size:20;index;not null"`
+	Role            string    `json:"role" gorm:"size:20"`
+	Currency        string    `json:"currency" gorm:"size:3"`
+	MinAmountCents  int64     `json:"min_amount_cents" gorm:"not null;default:0"`
+	MaxAmountCents  int64     `json:"max_amount_cents" gorm:"not null;default:0"`
+	FixedCents      int64     `json:"fixed_cents" gorm:"not null;default:0"`
+	PercentBps      int64     `json:"percent_bps" gorm:"not null;default:0"`
+	MinFeeCents     int64     `json:"min_fee_cents" gorm:"not null;default:0"`
+	MaxFeeCents     int64     `json:"max_fee_cents" gorm:"not null;default:0"`
+	Priority        int       `json:"priority" gorm:"not null;default:0"`
+	Active          bool      `json:"active" gorm:"not null"` // no default: GORM would drop an explicit false on insert
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (Rule) TableName() string { return "fee_rules" }
+
+// RuleRequest is the admin payload for creating or replacing a rule
+type RuleRequest struct {
+	Name            string `json:"name" binding:"required,max=100"`
+	TransactionType string `json:"transaction_type" binding:"required,oneof=transfer debit"`
+	Role            string `json:"role" binding:"omitempty,oneof=user moderator admin"`
+	Currency        string `json:"currency" binding:"omitempty,len=3"`
+	MinAmountCents  int64  `json:"min_amount_cents" binding:"gte=0"`
+	MaxAmountCents  int64  `json:"max_amount_cents" binding:"gte=0"`
+	FixedCents      int64  `json:"fixed_cents" binding:"gte=0"`
+	PercentBps      int64  `json:"percent_bps" binding:"gte=0,lte=10000"`
+	MinFeeCents     int64  `json:"min_fee_cents" binding:"gte=0"`
+	MaxFeeCents     int64  `json:"max_fee_cents" binding:"gte=0"`
+	Priority        int    `json:"priority"`
+	Active          *bool  `json:"active"`
+}
+
+// ToRule converts the request into a rule
+func (r RuleRequest) ToRule() Rule {
+	active := true
+	if r.Active != nil {
+		active = *r.Active
+	}
+	return Rule{
+		Name:            r.Name,
+		TransactionType: r.TransactionType,
+		Role:            r.Role,
+		Currency:        r.Currency,
+		MinAmountCents:  r.MinAmountCents,
+		MaxAmountCents:  r.MaxAmountCents,
+		FixedCents:      r.FixedCents,
+		PercentBps:      r.PercentBps,
+		MinFeeCents:     r.MinFeeCents,
+		MaxFeeCents:     r.MaxFeeCents,
+		Priority:        r.Priority,
+		Active:          active,
+	}
+}
+
+// Validate checks that the band and the min/cap are consistent
+func (r Rule) Validate() error {
+	if r.MaxAmountCents > 0 && r.MaxAmountCents < r.MinAmountCents {
+		return fmt.Errorf("max_amount_cents must not be below min_amount_cents")
+	}
+	if r.MaxFeeCents > 0 && r.MaxFeeCents < r.MinFeeCents {
+		return fmt.Errorf("max_fee_cents must not be below min_fee_cents")
+	}
+	return nil
+}
+
+// Matches reports whether the rule applies to a transaction
+func (r Rule) Matches(txType, role, currency string, amount int64) bool {
+	if !r.Active || r.TransactionType != txType {
+		return false
+	}
+	if r.Role != "" && r.Role != role {
+		return false
+	}
+	if r.Currency != "" && r.Currency != currency {
+		return false
+	}
+	if amount < r.MinAmountCents {
+		return false
+	}
+	if r.MaxAmountCents > 0 && amount > r.MaxAmountCents {
+		return false
+	}
+	return true
+}
+
+// Compute returns the fee for amount, rounding the percentage half up
+func (r Rule) Compute(amount int64) int64 {
+	fee := r.FixedCents + (amount*r.PercentBps+5000)/10000
+	if fee < r.MinFeeCents {
+		fee = r.MinFeeCents
+	}
+	if r.MaxFeeCents > 0 && fee > r.MaxFeeCents {
+		fee = r.MaxFeeCents
+	}
+	return fee
+}
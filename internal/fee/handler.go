@@ -0,0 +1,108 @@
+package fee
+
+import (
+	"bankapi/internal/audit"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct{}
+
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// ListRules returns every fee rule
+func (h *Handler) ListRules(c *gin.Context) {
+	rules, err := ListRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ücret kuralları getirilemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// GetRule returns a single fee rule
+func (h *Handler) GetRule(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	rule, err := GetRule(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// CreateRule adds a new fee rule
+func (h *Handler) CreateRule(c *gin.Context) {
+	var req RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule := req.ToRule()
+	if err := CreateRule(&rule); err != nil {
+		respondError(c, err)
+		return
+	}
+	audit.Log("fee_rule", fmt.Sprintf("%d", rule.ID), "create", rule.Name)
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateRule replaces a fee rule
+func (h *Handler) UpdateRule(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var req RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule, err := UpdateRule(id, req.ToRule())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	audit.Log("fee_rule", fmt.Sprintf("%d", rule.ID), "update", rule.Name)
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule removes a fee rule
+func (h *Handler) DeleteRule(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	if err := DeleteRule(id); err != nil {
+		respondError(c, err)
+		return
+	}
+	audit.Log("fee_rule", fmt.Sprintf("%d", id), "delete", "")
+	c.Status(http.StatusNoContent)
+}
+
+func parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kural ID geçersiz"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondError(c *gin.Context, err error) {
+	if errors.Is(err, ErrRuleNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ücret kuralı bulunamadı"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,24 @@
+package fee
+
+import (
+	"bankapi/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
+	fees := router.Group("/api/v1/fees")
+
+	// Only use auth middleware if it's provided
+	if authMiddleware != nil {
+		fees.Use(authMiddleware, middleware.RequireRoles("admin"))
+	}
+
+	handler := NewHandler()
+
+	fees.GET("/rules", handler.ListRules)
+	fees.POST("/rules", handler.CreateRule)
+	fees.GET("/rules/:id", handler.GetRule)
+	fees.PUT("/rules/:id", handler.UpdateRule)
+	fees.DELETE("/rules/:id", handler.DeleteRule)
+}
@@ -0,0 +1,87 @@
+package fee
+
+import (
+	"bankapi/internal/db"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+var ErrRuleNotFound = errors.New("fee rule not found")
+
+// Calculate finds the highest-priority active rule matching the transaction
+// and returns its fee. It returns zero and a nil rule when nothing matches.
+func Calculate(tx *gorm.DB, txType, role, currency string, amount int64) (int64, *Rule, error) {
+	var rules []Rule
+	if err := tx.Where("active = ? AND transaction_type = ?", true, txType).
+		Order("priority DESC, id ASC").
+		Find(&rules).Error; err != nil {
+		return 0, nil, fmt.Errorf("failed to load fee rules: %w", err)
+	}
+
+	for i := range rules {
+		if rules[i].Matches(txType, role, currency, amount) {
+			fee := rules[i].Compute(amount)
+			println("🧾 Ücret kuralı eşleşti:", rules[i].Name, "ücret:", fee)
+			return fee, &rules[i], nil
+		}
+	}
+	return 0, nil, nil
+}
+
+func ListRules() ([]Rule, error) {
+	var rules []Rule
+	if err := db.DB.Order("transaction_type, priority DESC, id").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to list fee rules: %w", err)
+	}
+	return rules, nil
+}
+
+func GetRule(id uint) (*Rule, error) {
+	var rule Rule
+	if err := db.DB.First(&rule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to load fee rule: %w", err)
+	}
+	return &rule, nil
+}
+
+func CreateRule(rule *Rule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	if err := db.DB.Create(rule).Error; err != nil {
+		return fmt.Errorf("failed to create fee rule: %w", err)
+	}
+	return nil
+}
+
+func UpdateRule(id uint, update Rule) (*Rule, error) {
+	rule, err := GetRule(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+	update.ID = rule.ID
+	update.CreatedAt = rule.CreatedAt
+	if err := db.DB.Save(&update).Error; err != nil {
+		return nil, fmt.Errorf("failed to update fee rule: %w", err)
+	}
+	return &update, nil
+}
+
+func DeleteRule(id uint) error {
+	res := db.DB.Delete(&Rule{}, id)
+	if res.Error != nil {
+		return fmt.Errorf("failed to delete fee rule: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrRuleNotFound
+	}
+	return nil
+}
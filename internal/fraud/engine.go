@@ -0,0 +1,153 @@
+package fraud
+
+import (
+	"bankapi/internal/db"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DefaultReviewScore = 50
+	DefaultBlockScore  = 100
+)
+
+// Engine runs every registered rule and sums their scores. A total at or
+// above reviewScore holds the transaction for review; at or above blockScore
+// it is rejected outright.
+type Engine struct {
+	mutex       sync.RWMutex
+	rules       []Rule
+	reviewScore int
+	blockScore  int
+}
+
+func NewEngine(reviewScore, blockScore int, rules ...Rule) *Engine {
+	return &Engine{rules: rules, reviewScore: reviewScore, blockScore: blockScore}
+}
+
+var (
+	defaultEngine *Engine
+	defaultOnce   sync.Once
+)
+
+// Default returns the shared engine loaded with the standard rule set
+func Default() *Engine {
+	defaultOnce.Do(func() {
+		defaultEngine = NewEngine(DefaultReviewScore, DefaultBlockScore,
+			VelocityRule{MaxCount: 5, Window: 10 * time.Minute, Score: 60},
+			NewBeneficiaryRule{ThresholdCents: 1_000_000, Score: 50},
+			AmountDeviationRule{Multiplier: 5, MinHistory: 5, Score: 40},
+			RoundAmountBurstRule{UnitCents: 100_000, MinCount: 3, Window: 30 * time.Minute, Score: 40},
+		)
+	})
+	return defaultEngine
+}
+
+// Register adds a rule to the engine
+func (e *Engine) Register(rule Rule) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.rules = append(e.rules, rule)
+}
+
+// Screen evaluates every rule against c and returns an unsaved decision
+func (e *Engine) Screen(tx *gorm.DB, c Candidate) (*Decision, error) {
+	e.mutex.RLock()
+	rules := append([]Rule(nil), e.rules...)
+	e.mutex.RUnlock()
+
+	decision := &Decision{TransactionID: c.TransactionID, UserID: c.FromUserID, Outcome: OutcomeAllow}
+	for _, rule := range rules {
+		hit, err := rule.Evaluate(tx, c)
+		if err != nil {
+			return nil, err
+		}
+		if hit != nil {
+			println("🚩 Fraud kuralı tetiklendi:", hit.Rule, "puan:", hit.Score)
+			decision.Hits = append(decision.Hits, *hit)
+			decision.Score += hit.Score
+		}
+	}
+
+	switch {
+	case decision.Score >= e.blockScore:
+		decision.Outcome = OutcomeBlock
+	case decision.Score >= e.reviewScore:
+		decision.Outcome = OutcomeReview
+		decision.ReviewStatus = ReviewStatusOpen
+	}
+	println("🛡️ Fraud taraması tamamlandı, sonuç:", string(decision.Outcome), "puan:", decision.Score)
+	return decision, nil
+}
+
+// SaveTx persists the decision and its hits for transactionID
+func SaveTx(tx *gorm.DB, decision *Decision, transactionID uint) error {
+	decision.TransactionID = transactionID
+	if err := tx.Create(decision).Error; err != nil {
+		return fmt.Errorf("failed to save fraud decision: %w", err)
+	}
+	return nil
+}
+
+// LockOpenReviewTx loads the open review decision of a transaction FOR UPDATE
+func LockOpenReviewTx(tx *gorm.DB, transactionID uint) (*Decision, error) {
+	var decision Decision
+	if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Where("transaction_id = ?", transactionID).
+		First(&decision).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotUnderReview
+		}
+		return nil, fmt.Errorf("failed to load fraud decision: %w", err)
+	}
+	if decision.ReviewStatus != ReviewStatusOpen {
+		return nil, ErrNotUnderReview
+	}
+	return &decision, nil
+}
+
+// ResolveTx closes an open review with the reviewer's verdict
+func ResolveTx(tx *gorm.DB, decision *Decision, status ReviewStatus, reviewerID *uint, note string) error {
+	now := time.Now()
+	decision.ReviewStatus = status
+	decision.ReviewedBy = reviewerID
+	decision.ReviewedAt = &now
+	decision.ReviewNote = note
+	if err := tx.Omit("Hits").Save(decision).Error; err != nil {
+		return fmt.Errorf("failed to update fraud decision: %w", err)
+	}
+	return nil
+}
+
+// ListDecisions returns decisions, newest first, filtered by outcome and
+// review status when given
+func ListDecisions(outcome, reviewStatus string, limit int) ([]Decision, error) {
+	q := db.DB.Preload("Hits").Order("created_at DESC, id DESC").Limit(limit)
+	if outcome != "" {
+		q = q.Where("outcome = ?", outcome)
+	}
+	if reviewStatus != "" {
+		q = q.Where("review_status = ?", reviewStatus)
+	}
+	var decisions []Decision
+	if err := q.Find(&decisions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list fraud decisions: %w", err)
+	}
+	return decisions, nil
+}
+
+func GetDecisionByTransaction(transactionID uint) (*Decision, error) {
+	var decision Decision
+	if err := db.DB.Preload("Hits").Where("transaction_id = ?", transactionID).First(&decision).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDecisionNotFound
+		}
+		return nil, fmt.Errorf("failed to load fraud decision: %w", err)
+	}
+	return &decision, nil
+}
@@ -0,0 +1,70 @@
+package fraud
+
+import (
+	"errors"
+	"time"
+)
+
+type Outcome string
+type ReviewStatus string
+
+const (
+	OutcomeAllow  Outcome = "allow"
+	OutcomeReview Outcome = "review"
+	OutcomeBlock  Outcome = "block"
+
+	ReviewStatusNone     ReviewStatus = ""
+	ReviewStatusOpen     ReviewStatus = "open"
+	ReviewStatusApproved ReviewStatus = "approved"
+	ReviewStatusRejected ReviewStatus = "rejected"
+)
+
+var (
+	ErrBlocked          = errors.New("transaction blocked by fraud screening")
+	ErrDecisionNotFound = errors.New("fraud decision not found")
+	ErrNotUnderReview   = errors.New("transaction is not under review")
+)
+
+// Candidate is the transaction being screened
+type Candidate struct {
+	TransactionID uint
+	Type          string
+	FromUserID    uint
+	ToUserID      uint
+	Currency      string
+	AmountCents   int64
+}
+
+// Decision is the persisted outcome of screening one transaction
+type Decision struct {
+	ID            uint         `json:"id" gorm:"primaryKey"`
+	TransactionID uint         `json:"transaction_id" gorm:"uniqueIndex;not null"`
+	UserID        uint         `json:"user_id" gorm:"index;not null"`
+	Outcome       Outcome      `json:"outcome" gorm:"size:10;index;not null"`
+	Score         int          `json:"score" gorm:"not null"`
+	ReviewStatus  ReviewStatus `json:"review_status" gorm:"size:10;index"`
+	ReviewedBy    *uint        `json:"reviewed_by,omitempty"`
+	ReviewedAt    *time.Time   `json:"reviewed_at,omitempty"`
+	ReviewNote    string       `json:"review_note,omitempty" gorm:"size:255"`
+	Hits          []RuleHit    `json:"hits" gorm:"foreignKey:DecisionID"`
+	CreatedAt     time.Time    `json:"created_at" gorm:"index"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+func (Decision) TableName() string { return "fraud_decisions" }
+
+// RuleHit records a rule that fired and what it contributed to the score
+type RuleHit struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	DecisionID uint      `json:"decision_id" gorm:"index;not null"`
+	Rule       string    `json:"rule" gorm:"size:50;not null"`
+	Score      int       `json:"score" gorm:"not null"`
+	Reason     string    `json:"reason" gorm:"size:255"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (RuleHit) TableName() string { return "fraud_rule_hits" }
+
+type ReviewRequest struct {
+	Note string `json:"note" binding:"max=255"`
+}
@@ -0,0 +1,54 @@
+package fraud
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct{}
+
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// ListDecisions returns screening decisions, optionally filtered by
+// ?outcome=allow|review|block and ?review_status=open|approved|rejected
+func (h *Handler) ListDecisions(c *gin.Context) {
+	limit := 100
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit geçersiz"})
+			return
+		}
+		limit = n
+	}
+	decisions, err := ListDecisions(c.Query("outcome"), c.Query("review_status"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "fraud kararları getirilemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, decisions)
+}
+
+// GetTransactionDecision returns the decision made for a transaction
+func (h *Handler) GetTransactionDecision(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "işlem ID geçersiz"})
+		return
+	}
+	decision, err := GetDecisionByTransaction(uint(id))
+	if err != nil {
+		if errors.Is(err, ErrDecisionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "fraud kararı bulunamadı"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "fraud kararı getirilemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, decision)
+}
@@ -0,0 +1,21 @@
+package fraud
+
+import (
+	"bankapi/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
+	f := router.Group("/api/v1/fraud")
+
+	// Only use auth middleware if it's provided
+	if authMiddleware != nil {
+		f.Use(authMiddleware, middleware.RequireRoles("admin"))
+	}
+
+	handler := NewHandler()
+
+	f.GET("/decisions", handler.ListDecisions)
+	f.GET("/transactions/:id", handler.GetTransactionDecision)
+}
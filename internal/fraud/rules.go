@@ -0,0 +1,142 @@
+package fraud
+
+import (
+	"bankapi/internal/currency"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Rule inspects a candidate and returns a hit when it looks suspicious.
+// Rules only read; the engine persists the decision.
+type Rule interface {
+	Name() string
+	Evaluate(tx *gorm.DB, c Candidate) (*RuleHit, error)
+}
+
+// countedStatuses are the transaction statuses that count as history
+var countedStatuses = []string{"pending", "pending_review", "completed", "partially_refunded", "refunded", "reversed"}
+
+// VelocityRule fires when the user already sent MaxCount transfers within Window
+type VelocityRule struct {
+	MaxCount int64
+	Window   time.Duration
+	Score    int
+}
+
+func (r VelocityRule) Name() string { return "velocity" }
+
+func (r VelocityRule) Evaluate(tx *gorm.DB, c Candidate) (*RuleHit, error) {
+	var count int64
+	if err := tx.Table("transactions").
+		Where("from_user_id = ? AND type = ? AND created_at >= ? AND id <> ?", c.FromUserID, c.Type, time.Now().Add(-r.Window), c.TransactionID).
+		Where("status IN ?", countedStatuses).
+		Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("velocity rule: %w", err)
+	}
+	if count < r.MaxCount {
+		return nil, nil
+	}
+	return &RuleHit{Rule: r.Name(), Score: r.Score, Reason: fmt.Sprintf("%d transfers in the last %s", count+1, r.Window)}, nil
+}
+
+// NewBeneficiaryRule fires on the first transfer to a receiver above ThresholdCents
+type NewBeneficiaryRule struct {
+	ThresholdCents int64
+	Score          int
+}
+
+func (r NewBeneficiaryRule) Name() string { return "new_beneficiary" }
+
+func (r NewBeneficiaryRule) Evaluate(tx *gorm.DB, c Candidate) (*RuleHit, error) {
+	amount, err := toBaseCurrency(c.AmountCents, c.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if amount <= r.ThresholdCents {
+		return nil, nil
+	}
+	var count int64
+	if err := tx.Table("transactions").
+		Where("from_user_id = ? AND to_user_id = ? AND type = ? AND status = ? AND id <> ?", c.FromUserID, c.ToUserID, c.Type, "completed", c.TransactionID).
+		Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("new beneficiary rule: %w", err)
+	}
+	if count > 0 {
+		return nil, nil
+	}
+	return &RuleHit{Rule: r.Name(), Score: r.Score, Reason: fmt.Sprintf("first transfer to user %d above %d %s", c.ToUserID, r.ThresholdCents, currency.DefaultCurrency)}, nil
+}
+
+// AmountDeviationRule fires when the amount is more than Multiplier times the
+// user's mean transfer in the same currency. Users with fewer than MinHistory
+// past transfers are not judged.
+type AmountDeviationRule struct {
+	Multiplier float64
+	MinHistory int64
+	Score      int
+}
+
+func (r AmountDeviationRule) Name() string { return "amount_deviation" }
+
+func (r AmountDeviationRule) Evaluate(tx *gorm.DB, c Candidate) (*RuleHit, error) {
+	var stats struct {
+		Count int64
+		Mean  float64
+	}
+	if err := tx.Table("transactions").
+		Select("COUNT(*) AS count, COALESCE(AVG(amount_cents), 0) AS mean").
+		Where("from_user_id = ? AND type = ? AND currency = ? AND status = ? AND id <> ?", c.FromUserID, c.Type, c.Currency, "completed", c.TransactionID).
+		Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("amount deviation rule: %w", err)
+	}
+	if stats.Count < r.MinHistory || stats.Mean <= 0 {
+		return nil, nil
+	}
+	if float64(c.AmountCents) <= stats.Mean*r.Multiplier {
+		return nil, nil
+	}
+	return &RuleHit{Rule: r.Name(), Score: r.Score, Reason: fmt.Sprintf("amount %d is %.1fx the mean of %.0f", c.AmountCents, float64(c.AmountCents)/stats.Mean, stats.Mean)}, nil
+}
+
+// RoundAmountBurstRule fires when a round amount (a multiple of UnitCents)
+// follows MinCount-1 other round transfers within Window.
+type RoundAmountBurstRule struct {
+	UnitCents int64
+	MinCount  int64
+	Window    time.Duration
+	Score     int
+}
+
+func (r RoundAmountBurstRule) Name() string { return "round_amount_burst" }
+
+func (r RoundAmountBurstRule) Evaluate(tx *gorm.DB, c Candidate) (*RuleHit, error) {
+	if c.AmountCents%r.UnitCents != 0 {
+		return nil, nil
+	}
+	var count int64
+	if err := tx.Table("transactions").
+		Where("from_user_id = ? AND type = ? AND created_at >= ? AND id <> ?", c.FromUserID, c.Type, time.Now().Add(-r.Window), c.TransactionID).
+		Where("amount_cents % ? = 0", r.UnitCents).
+		Where("status IN ?", countedStatuses).
+		Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("round amount rule: %w", err)
+	}
+	if count+1 < r.MinCount {
+		return nil, nil
+	}
+	return &RuleHit{Rule: r.Name(), Score: r.Score, Reason: fmt.Sprintf("%d round-amount transfers in the last %s", count+1, r.Window)}, nil
+}
+
+func toBaseCurrency(amount int64, cur string) (int64, error) {
+	if cur == currency.DefaultCurrency {
+		return amount, nil
+	}
+	converted, err := currency.Default().Convert(float64(amount), cur, currency.DefaultCurrency)
+	if err != nil {
+		return 0, fmt.Errorf("failed to convert %s: %w", cur, err)
+	}
+	return int64(math.Round(converted)), nil
+}
@@ -0,0 +1,64 @@
+package idempotency
+
+import (
+	"bankapi/internal/db"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+
+	// KeyTTL is how long a stored response can be replayed
+	KeyTTL = 24 * time.Hour
+
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed"
+)
+
+// Record stores the outcome of the first request made with an idempotency key
+type Record struct {
+	Key          string    `json:"key" gorm:"primaryKey;size:255"`
+	Fingerprint  string    `json:"fingerprint" gorm:"size:64;not null"`
+	Status       string    `json:"status" gorm:"size:20;not null"`
+	StatusCode   int       `json:"status_code"`
+	ContentType  string    `json:"content_type" gorm:"size:100"`
+	ResponseBody string    `json:"response_body" gorm:"type:text"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (Record) TableName() string { return "idempotency_keys" }
+
+// IsExpired reports whether the record can no longer be replayed
+func (r Record) IsExpired() bool {
+	return time.Since(r.CreatedAt) > KeyTTL
+}
+
+// PurgeExpired deletes the records that can no longer be replayed, including
+// claims left in progress by a request that never finished, and returns how
+// many were removed
+func PurgeExpired() (int64, error) {
+	res := db.DB.Where("created_at < ?", time.Now().Add(-KeyTTL)).Delete(&Record{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", res.Error)
+	}
+	if res.RowsAffected > 0 {
+		println("🧹 Süresi dolmuş idempotency kayıtları silindi:", res.RowsAffected)
+	}
+	return res.RowsAffected, nil
+}
+
+// Fingerprint hashes the parts of a request that must match on replay
+func Fingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,164 @@
+package idempotency
+
+import (
+	"bankapi/internal/db"
+	"bankapi/internal/middleware"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+// bodyRecorder copies everything the handler writes so it can be stored
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Middleware honours the Idempotency-Key header. The first request with a key
+// runs normally and its response is stored; retries with the same body get
+// the stored response back, and reusing a key for a different body is a 409.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderKey)
+		if key == "" || db.DB == nil {
+			c.Next()
+			return
+		}
+
+		if len(key) > 200 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key çok uzun"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "İstek gövdesi okunamadı"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Keys are scoped per caller so two users can never collide
+		scope := "anonymous"
+		if u, ok := middleware.CurrentUser(c); ok {
+			scope = fmt.Sprintf("user:%d", u.ID)
+		}
+		storageKey := fmt.Sprintf("%s:%s:%s", scope, c.FullPath(), key)
+		fingerprint := Fingerprint(c.Request.Method, c.FullPath(), body)
+
+		claimed, existing, err := claim(storageKey, fingerprint)
+		if err != nil {
+			println("❌ Idempotency kaydı oluşturulamadı:", err.Error())
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Idempotency kontrolü yapılamadı"})
+			return
+		}
+
+		if !claimed {
+			replay(c, existing, fingerprint)
+			return
+		}
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		// A panicking handler must not leave the key in progress until it
+		// expires; release it and let the recovery middleware answer
+		defer func() {
+			if r := recover(); r != nil {
+				release(storageKey)
+				panic(r)
+			}
+			complete(storageKey, recorder)
+		}()
+		c.Next()
+	}
+}
+
+// claim inserts an in-progress record for the key. It returns false and the
+// stored record if another request already owns the key.
+func claim(storageKey, fingerprint string) (bool, *Record, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		rec := Record{Key: storageKey, Fingerprint: fingerprint, Status: StatusInProgress}
+		res := db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&rec)
+		if res.Error != nil {
+			return false, nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			return true, nil, nil
+		}
+
+		var existing Record
+		if err := db.DB.First(&existing, "key = ?", storageKey).Error; err != nil {
+			// Deleted between the insert and the read, try again
+			continue
+		}
+		if !existing.IsExpired() {
+			return false, &existing, nil
+		}
+
+		println("🧹 Süresi dolmuş idempotency kaydı siliniyor:", storageKey)
+		if err := db.DB.Where("key = ? AND created_at = ?", storageKey, existing.CreatedAt).Delete(&Record{}).Error; err != nil {
+			return false, nil, err
+		}
+	}
+	return false, nil, fmt.Errorf("could not claim idempotency key %s", storageKey)
+}
+
+func replay(c *gin.Context, rec *Record, fingerprint string) {
+	if rec.Fingerprint != fingerprint {
+		println("❌ Idempotency-Key farklı bir istek gövdesiyle tekrar kullanıldı")
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Idempotency-Key farklı bir istek için kullanılmış"})
+		return
+	}
+
+	if rec.Status != StatusCompleted {
+		println("⏳ Aynı Idempotency-Key ile istek hâlâ işleniyor")
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Bu Idempotency-Key ile bir istek hâlâ işleniyor"})
+		return
+	}
+
+	println("🔁 Kayıtlı yanıt tekrar gönderiliyor, status:", rec.StatusCode)
+	c.Header(HeaderReplayed, "true")
+	c.Data(rec.StatusCode, rec.ContentType, []byte(rec.ResponseBody))
+	c.Abort()
+}
+
+// complete stores the response, or releases the key after a server error so
+// the client can safely retry.
+func complete(storageKey string, recorder *bodyRecorder) {
+	status := recorder.Status()
+	if status >= http.StatusInternalServerError {
+		release(storageKey)
+		return
+	}
+
+	updates := map[string]interface{}{
+		"status":        StatusCompleted,
+		"status_code":   status,
+		"content_type":  recorder.Header().Get("Content-Type"),
+		"response_body": recorder.body.String(),
+	}
+	if err := db.DB.Model(&Record{}).Where("key = ?", storageKey).Updates(updates).Error; err != nil {
+		println("⚠️ Idempotency yanıtı kaydedilemedi:", err.Error())
+	}
+}
+
+// release deletes the claim on a key so a retry runs the request again
+func release(storageKey string) {
+	if err := db.DB.Where("key = ?", storageKey).Delete(&Record{}).Error; err != nil {
+		println("⚠️ Idempotency kaydı silinemedi:", err.Error())
+	}
+}
@@ -0,0 +1,239 @@
+package interest
+
+import (
+	"bankapi/internal/audit"
+	"bankapi/internal/middleware"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct{}
+
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// ListTiers returns every interest tier
+func (h *Handler) ListTiers(c *gin.Context) {
+	tiers, err := ListTiers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "faiz kademeleri getirilemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, tiers)
+}
+
+// CreateTier adds a new interest tier
+func (h *Handler) CreateTier(c *gin.Context) {
+	var req TierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tier := req.ToTier()
+	if err := CreateTier(&tier); err != nil {
+		respondError(c, err)
+		return
+	}
+	audit.Log("interest_tier", fmt.Sprintf("%d", tier.ID), "create", fmt.Sprintf("%s %s min=%d rate=%d %s", tier.Product, tier.Currency, tier.MinBalanceCents, tier.RateBps, tier.DayCount))
+	c.JSON(http.StatusCreated, tier)
+}
+
+// UpdateTier replaces an interest tier
+func (h *Handler) UpdateTier(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var req TierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tier, err := UpdateTier(id, req.ToTier())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	audit.Log("interest_tier", fmt.Sprintf("%d", tier.ID), "update", fmt.Sprintf("%s %s min=%d rate=%d %s", tier.Product, tier.Currency, tier.MinBalanceCents, tier.RateBps, tier.DayCount))
+	c.JSON(http.StatusOK, tier)
+}
+
+// DeleteTier removes an interest tier
+func (h *Handler) DeleteTier(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	if err := DeleteTier(id); err != nil {
+		respondError(c, err)
+		return
+	}
+	audit.Log("interest_tier", fmt.Sprintf("%d", id), "delete", "")
+	c.Status(http.StatusNoContent)
+}
+
+// ListAccruals returns daily accruals for ?user_id, ?account_id, ?currency and the
+// ?from/?to dates. Non-admins only see their own.
+func (h *Handler) ListAccruals(c *gin.Context) {
+	userID, accountID, ok := scopeUser(c)
+	if !ok {
+		return
+	}
+	limit, ok := parseLimit(c)
+	if !ok {
+		return
+	}
+	var from, to time.Time
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from tarih formatı YYYY-MM-DD olmalı"})
+			return
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to tarih formatı YYYY-MM-DD olmalı"})
+			return
+		}
+	}
+	accruals, err := ListAccruals(userID, accountID, strings.ToUpper(c.Query("currency")), from, to, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "faiz tahakkukları getirilemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, accruals)
+}
+
+// ListCapitalizations returns monthly interest payments. Non-admins only see
+// their own.
+func (h *Handler) ListCapitalizations(c *gin.Context) {
+	userID, accountID, ok := scopeUser(c)
+	if !ok {
+		return
+	}
+	limit, ok := parseLimit(c)
+	if !ok {
+		return
+	}
+	caps, err := ListCapitalizations(userID, accountID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "faiz ödemeleri getirilemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, caps)
+}
+
+// RunAccrual accrues interest for ?date (YYYY-MM-DD, default yesterday)
+func (h *Handler) RunAccrual(c *gin.Context) {
+	day := time.Now().AddDate(0, 0, -1)
+	if v := c.Query("date"); v != "" {
+		var err error
+		if day, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date formatı YYYY-MM-DD olmalı"})
+			return
+		}
+	}
+	if !startOfDay(day).Before(startOfDay(time.Now())) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "yalnızca geçmiş günler için faiz tahakkuk ettirilebilir"})
+		return
+	}
+	n, err := AccrueDay(day)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"date": day.Format("2006-01-02"), "accruals": n})
+}
+
+// RunCapitalization capitalizes accruals dated before ?before (YYYY-MM-DD,
+// default the first day of the current month)
+func (h *Handler) RunCapitalization(c *gin.Context) {
+	now := time.Now()
+	before := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	if v := c.Query("before"); v != "" {
+		var err error
+		if before, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "before formatı YYYY-MM-DD olmalı"})
+			return
+		}
+	}
+	caps, err := Capitalize(before)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"before": before.Format("2006-01-02"), "capitalizations": caps})
+}
+
+// scopeUser returns the ?user_id and ?account_id filters, with the user
+// forced to the caller for non-admins
+func scopeUser(c *gin.Context) (uint, uint, bool) {
+	u, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Kimlik doğrulaması gerekli"})
+		return 0, 0, false
+	}
+	var userID, accountID uint
+	if v := c.Query("user_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || id == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "user_id geçersiz"})
+			return 0, 0, false
+		}
+		userID = uint(id)
+	}
+	if v := c.Query("account_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || id == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "account_id geçersiz"})
+			return 0, 0, false
+		}
+		accountID = uint(id)
+	}
+	if !u.IsAdmin() {
+		if userID != 0 && userID != u.ID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Erişim reddedildi"})
+			return 0, 0, false
+		}
+		userID = u.ID
+	}
+	return userID, accountID, true
+}
+
+func parseLimit(c *gin.Context) (int, bool) {
+	limit := 100
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit geçersiz"})
+			return 0, false
+		}
+		limit = n
+	}
+	return limit, true
+}
+
+func parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kademe ID geçersiz"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondError(c *gin.Context, err error) {
+	if errors.Is(err, ErrTierNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "faiz kademesi bulunamadı"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
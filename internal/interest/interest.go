@@ -0,0 +1,203 @@
+package interest
+
+import (
+	"bankapi/internal/account"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"time"
+)
+
+type DayCount string
+type Kind string
+
+const (
+	DayCountACT365 DayCount = "ACT/365"
+	DayCount30360  DayCount = "30/360"
+)
+
+const (
+	// KindCredit is interest the bank pays on positive balances
+	KindCredit Kind = "credit"
+	// KindOverdraft is interest the bank charges on negative balances
+	KindOverdraft Kind = "overdraft"
+)
+
+// DefaultProduct is the account type a tier applies to when none is given.
+// Balances accrue under the tiers of their account's type.
+const DefaultProduct = string(account.TypeSavings)
+
+// MicroCentsPerCent is the precision accruals are stored in, so interest on
+// small balances is not lost to rounding day by day
+const MicroCentsPerCent = 1_000_000
+
+// OverdraftDayCount is the convention overdraft interest accrues under
+const OverdraftDayCount = DayCountACT365
+
+var (
+	withholdingBps atomic.Int64
+	overdraftBps   atomic.Int64
+)
+
+func init() {
+	withholdingBps.Store(1500)
+	overdraftBps.Store(3600)
+}
+
+// SetWithholdingRate sets the withholding tax rate in basis points
+func SetWithholdingRate(bps int64) {
+	withholdingBps.Store(bps)
+}
+
+// WithholdingRate returns the withholding tax rate in basis points
+func WithholdingRate() int64 {
+	return withholdingBps.Load()
+}
+
+// SetOverdraftRate sets the default annual overdraft rate in basis points,
+// used for balances without a rate of their own
+func SetOverdraftRate(bps int64) {
+	overdraftBps.Store(bps)
+}
+
+// OverdraftRate returns the default annual overdraft rate in basis points
+func OverdraftRate() int64 {
+	return overdraftBps.Load()
+}
+
+// Tier is the annual rate paid on balances of a product and currency from
+// MinBalanceCents upwards. The tier with the highest minimum not above the
+// balance applies to the whole balance.
+type Tier struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	Product         string    `json:"product" gorm:"size:50;not null;index"`
+	Currency        string    `json:"currency" gorm:"size:3;not null"`
+	MinBalanceCents int64     `json:"min_balance_cents" gorm:"not null;default:0"`
+	RateBps         int64     `json:"rate_bps" gorm:"not null"`
+	DayCount        DayCount  `json:"day_count" gorm:"size:10;not null"`
+	Active          bool      `json:"active" gorm:"not null"` // no default: GORM would drop an explicit false on insert
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (Tier) TableName() string { return "interest_tiers" }
+
+// TierRequest is the admin payload for creating or replacing a tier
+type TierRequest struct {
+	Product         string   `json:"product" binding:"omitempty,oneof=checking savings"`
+	Currency        string   `json:"currency" binding:"required,len=3"`
+	MinBalanceCents int64    `json:"min_balance_cents" binding:"gte=0"`
+	RateBps         int64    `json:"rate_bps" binding:"gte=0,lte=100000"`
+	DayCount        DayCount `json:"day_count" binding:"omitempty,oneof=ACT/365 30/360"`
+	Active          *bool    `json:"active"`
+}
+
+// ToTier converts the request into a tier
+func (r TierRequest) ToTier() Tier {
+	active := true
+	if r.Active != nil {
+		active = *r.Active
+	}
+	product := r.Product
+	if product == "" {
+		product = DefaultProduct
+	}
+	dayCount := r.DayCount
+	if dayCount == "" {
+		dayCount = DayCountACT365
+	}
+	return Tier{
+		Product:         product,
+		Currency:        r.Currency,
+		MinBalanceCents: r.MinBalanceCents,
+		RateBps:         r.RateBps,
+		DayCount:        dayCount,
+		Active:          active,
+	}
+}
+
+// Accrual is one day of interest on one balance. Amounts are in micro-cents
+// and always positive; Kind tells who pays whom.
+type Accrual struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	AccountID        uint      `json:"account_id" gorm:"not null;uniqueIndex:idx_interest_accrual_day"`
+	Currency         string    `json:"currency" gorm:"size:3;not null;uniqueIndex:idx_interest_accrual_day"`
+	Kind             Kind      `json:"kind" gorm:"size:10;not null;default:credit;uniqueIndex:idx_interest_accrual_day"`
+	AccrualDate      time.Time `json:"accrual_date" gorm:"type:date;not null;uniqueIndex:idx_interest_accrual_day"`
+	BalanceCents     int64     `json:"balance_cents" gorm:"not null"`
+	TierID           *uint     `json:"tier_id,omitempty"`
+	RateBps          int64     `json:"rate_bps" gorm:"not null"`
+	DayCount         DayCount  `json:"day_count" gorm:"size:10;not null"`
+	AmountMicroCents int64     `json:"amount_micro_cents" gorm:"not null"`
+	CapitalizationID *uint     `json:"capitalization_id,omitempty" gorm:"index"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func (Accrual) TableName() string { return "interest_accruals" }
+
+// Capitalization is one month of accrued interest booked on a balance. For
+// overdraft interest GrossCents is the charge and no tax is withheld.
+type Capitalization struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	AccountID        uint      `json:"account_id" gorm:"index;not null"`
+	Currency         string    `json:"currency" gorm:"size:3;not null"`
+	Kind             Kind      `json:"kind" gorm:"size:10;not null;default:credit"`
+	PeriodStart      time.Time `json:"period_start" gorm:"type:date"`
+	PeriodEnd        time.Time `json:"period_end" gorm:"type:date"`
+	AccrualCount     int       `json:"accrual_count"`
+	GrossCents       int64     `json:"gross_cents"`
+	TaxRateBps       int64     `json:"tax_rate_bps"`
+	TaxCents         int64     `json:"tax_cents"`
+	NetCents         int64     `json:"net_cents"`
+	TransactionID    uint      `json:"transaction_id"`
+	TaxTransactionID *uint     `json:"tax_transaction_id,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func (Capitalization) TableName() string { return "interest_capitalizations" }
+
+// dayFraction returns the share of a year that day counts for as
+// numerator/denominator under the convention
+func dayFraction(dc DayCount, day time.Time) (int64, int64, error) {
+	switch dc {
+	case DayCountACT365:
+		return 1, 365, nil
+	case DayCount30360:
+		return days30360(day, day.AddDate(0, 0, 1)), 360, nil
+	default:
+		return 0, 0, fmt.Errorf("unsupported day count: %s", dc)
+	}
+}
+
+// days30360 counts the days between from and to with every month taken as
+// 30 days (30/360 bond basis)
+func days30360(from, to time.Time) int64 {
+	y1, m1, d1 := from.Date()
+	y2, m2, d2 := to.Date()
+	if d1 == 31 {
+		d1 = 30
+	}
+	if d2 == 31 && d1 == 30 {
+		d2 = 30
+	}
+	return int64(360*(y2-y1) + 30*(int(m2)-int(m1)) + (d2 - d1))
+}
+
+// dailyInterest returns the interest earned on balance for day in
+// micro-cents, rounded half up
+func dailyInterest(balance, rateBps int64, dc DayCount, day time.Time) (int64, error) {
+	num, den, err := dayFraction(dc, day)
+	if err != nil {
+		return 0, err
+	}
+	// balance * rate/10000 * num/den, scaled to micro-cents
+	n := new(big.Int).Mul(big.NewInt(balance), big.NewInt(rateBps*num*MicroCentsPerCent))
+	d := big.NewInt(10000 * den)
+	n.Add(n, new(big.Int).Quo(d, big.NewInt(2)))
+	return n.Quo(n, d).Int64(), nil
+}
+
+// withholding returns the tax withheld from gross at bps, rounded half up
+func withholding(gross, bps int64) int64 {
+	return (gross*bps + 5000) / 10000
+}
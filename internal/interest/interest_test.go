@@ -0,0 +1,110 @@
+package interest
+
+import (
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestDays30360(t *testing.T) {
+	tests := []struct {
+		from, to time.Time
+		want     int64
+	}{
+		{date(2024, 3, 15), date(2024, 3, 16), 1},
+		{date(2024, 1, 30), date(2024, 1, 31), 0}, // the 31st counts as the 30th
+		{date(2024, 1, 31), date(2024, 2, 1), 1},
+		{date(2023, 2, 28), date(2023, 3, 1), 3}, // February is topped up to 30 days
+		{date(2024, 2, 28), date(2024, 2, 29), 1},
+		{date(2024, 2, 29), date(2024, 3, 1), 2},
+		{date(2023, 12, 31), date(2024, 1, 1), 1},
+		{date(2024, 1, 1), date(2025, 1, 1), 360},
+		{date(2024, 1, 15), date(2024, 7, 15), 180},
+	}
+	for _, tt := range tests {
+		if got := days30360(tt.from, tt.to); got != tt.want {
+			t.Errorf("days30360(%s, %s) = %d, want %d", tt.from.Format("2006-01-02"), tt.to.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+// Under 30/360 the daily fractions of every month add up to 30 days
+func TestDays30360MonthTotals(t *testing.T) {
+	for _, year := range []int{2023, 2024} {
+		for m := time.January; m <= time.December; m++ {
+			var total int64
+			for d := date(year, m, 1); d.Month() == m; d = d.AddDate(0, 0, 1) {
+				num, den, err := dayFraction(DayCount30360, d)
+				if err != nil || den != 360 {
+					t.Fatalf("dayFraction(%s) = %d/%d, %v", d.Format("2006-01-02"), num, den, err)
+				}
+				total += num
+			}
+			if total != 30 {
+				t.Errorf("%d-%02d counts %d days, want 30", year, m, total)
+			}
+		}
+	}
+}
+
+func TestDailyInterest(t *testing.T) {
+	tests := []struct {
+		name    string
+		balance int64
+		rateBps int64
+		dc      DayCount
+		day     time.Time
+		want    int64 // micro-cents
+	}{
+		{"exact ACT/365", 10_000_000, 3650, DayCountACT365, date(2024, 3, 15), 10_000_000_000},
+		{"rounds down below half", 1, 1, DayCountACT365, date(2024, 3, 15), 0},                // 0.274
+		{"sub-cent accrual kept", 100, 1, DayCountACT365, date(2024, 3, 15), 27},              // 27.397
+		{"ACT/365 rounds down", 12345, 4250, DayCountACT365, date(2024, 3, 15), 14_374_315},   // .068
+		{"30/360 regular day", 12345, 4250, DayCount30360, date(2024, 3, 15), 14_573_958},     // .333
+		{"30/360 end of February", 12345, 4250, DayCount30360, date(2023, 2, 28), 43_721_875}, // three days
+		{"30/360 day before the 31st", 12345, 4250, DayCount30360, date(2024, 1, 30), 0},      // 30th to 31st is no day
+		{"30/360 the 31st", 12345, 4250, DayCount30360, date(2024, 1, 31), 14_573_958},
+		{"exact half rounds up", 9, 1, DayCount30360, date(2024, 3, 15), 3},  // 2.5
+		{"above half rounds up", 1, 9, DayCountACT365, date(2024, 3, 15), 2}, // 2.466
+		{"zero rate", 12345, 0, DayCountACT365, date(2024, 3, 15), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dailyInterest(tt.balance, tt.rateBps, tt.dc, tt.day)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("dailyInterest(%d, %d, %s, %s) = %d, want %d", tt.balance, tt.rateBps, tt.dc, tt.day.Format("2006-01-02"), got, tt.want)
+			}
+		})
+	}
+
+	if _, err := dailyInterest(100, 100, DayCount("ACT/ACT"), date(2024, 3, 15)); err == nil {
+		t.Fatal("unsupported day count was accepted")
+	}
+}
+
+func TestWithholding(t *testing.T) {
+	tests := []struct {
+		gross, bps, want int64
+	}{
+		{10_000, 1500, 1500},
+		{1, 1500, 0},  // 0.15
+		{3, 1500, 0},  // 0.45
+		{4, 1500, 1},  // 0.6
+		{10, 500, 1},  // 0.5 rounds up
+		{30, 1500, 5}, // 4.5 rounds up
+		{29, 1500, 4}, // 4.35
+		{12_345, 0, 0},
+		{12_345, 10_000, 12_345},
+	}
+	for _, tt := range tests {
+		if got := withholding(tt.gross, tt.bps); got != tt.want {
+			t.Errorf("withholding(%d, %d) = %d, want %d", tt.gross, tt.bps, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package interest
+
+import (
+	"bankapi/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
+	i := router.Group("/api/v1/interest")
+
+	// Only use auth middleware if it's provided
+	if authMiddleware != nil {
+		i.Use(authMiddleware)
+	}
+
+	handler := NewHandler()
+	admin := middleware.RequireRoles("admin")
+
+	i.GET("/tiers", handler.ListTiers)
+	i.POST("/tiers", admin, handler.CreateTier)
+	i.PUT("/tiers/:id", admin, handler.UpdateTier)
+	i.DELETE("/tiers/:id", admin, handler.DeleteTier)
+	i.GET("/accruals", handler.ListAccruals)
+	i.GET("/capitalizations", handler.ListCapitalizations)
+	i.POST("/accrue", admin, handler.RunAccrual)
+	i.POST("/capitalize", admin, handler.RunCapitalization)
+}
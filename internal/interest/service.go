@@ -0,0 +1,365 @@
+package interest
+
+import (
+	"bankapi/internal/balance"
+	"bankapi/internal/currency"
+	"bankapi/internal/db"
+	"bankapi/internal/logger"
+	"bankapi/internal/transaction"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrTierNotFound = errors.New("interest tier not found")
+
+// Validate checks the tier's currency
+func (t Tier) Validate() error {
+	if !currency.Default().IsSupported(t.Currency) {
+		return fmt.Errorf("unsupported currency: %s", t.Currency)
+	}
+	if _, _, err := dayFraction(t.DayCount, time.Now()); err != nil {
+		return err
+	}
+	return nil
+}
+
+func ListTiers() ([]Tier, error) {
+	var tiers []Tier
+	if err := db.DB.Order("product, currency, min_balance_cents").Find(&tiers).Error; err != nil {
+		return nil, fmt.Errorf("failed to list interest tiers: %w", err)
+	}
+	return tiers, nil
+}
+
+func GetTier(id uint) (*Tier, error) {
+	var tier Tier
+	if err := db.DB.First(&tier, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTierNotFound
+		}
+		return nil, fmt.Errorf("failed to load interest tier: %w", err)
+	}
+	return &tier, nil
+}
+
+func CreateTier(tier *Tier) error {
+	if err := tier.Validate(); err != nil {
+		return err
+	}
+	if err := db.DB.Create(tier).Error; err != nil {
+		return fmt.Errorf("failed to create interest tier: %w", err)
+	}
+	return nil
+}
+
+func UpdateTier(id uint, update Tier) (*Tier, error) {
+	tier, err := GetTier(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+	update.ID = tier.ID
+	update.CreatedAt = tier.CreatedAt
+	if err := db.DB.Save(&update).Error; err != nil {
+		return nil, fmt.Errorf("failed to update interest tier: %w", err)
+	}
+	return &update, nil
+}
+
+func DeleteTier(id uint) error {
+	res := db.DB.Delete(&Tier{}, id)
+	if res.Error != nil {
+		return fmt.Errorf("failed to delete interest tier: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrTierNotFound
+	}
+	return nil
+}
+
+// selectTier returns the tier with the highest minimum not above balance, or
+// nil when no tier of the product and currency applies. tiers must be
+// ordered by MinBalanceCents descending.
+func selectTier(tiers []Tier, product, cur string, balance int64) *Tier {
+	for i := range tiers {
+		t := &tiers[i]
+		if t.Product == product && t.Currency == cur && balance >= t.MinBalanceCents {
+			return t
+		}
+	}
+	return nil
+}
+
+// startOfDay truncates t to midnight in its location
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// AccrueDay records one day of interest on every closing balance of day:
+// tiered credit interest on positive balances and overdraft interest on
+// negative ones. The closing balance is read from balance history, so a
+// missed day can be accrued later. Running it twice for the same day adds
+// nothing.
+func AccrueDay(day time.Time) (int64, error) {
+	start := startOfDay(day)
+	end := start.AddDate(0, 0, 1)
+	println("🏦 Faiz tahakkuku başlıyor, gün:", start.Format("2006-01-02"))
+
+	var tiers []Tier
+	if err := db.DB.Where("active = ?", true).Order("min_balance_cents DESC").Find(&tiers).Error; err != nil {
+		return 0, fmt.Errorf("failed to load interest tiers: %w", err)
+	}
+
+	// Balances with an overdraft rate of their own
+	var arranged []balance.Balance
+	if err := db.DB.Where("overdraft_rate_bps > 0").Find(&arranged).Error; err != nil {
+		return 0, fmt.Errorf("failed to load overdraft rates: %w", err)
+	}
+	overdraftRates := make(map[balance.Key]int64, len(arranged))
+	for _, b := range arranged {
+		overdraftRates[b.Key()] = b.OverdraftRateBps
+	}
+	defaultOverdraft := OverdraftRate()
+
+	type closing struct {
+		AccountID   uint
+		AccountType string
+		Currency    string
+		AmountCents int64
+	}
+	var balances []closing
+	if err := db.DB.Raw(`SELECT DISTINCT ON (h.account_id, h.currency) h.account_id, a.type AS account_type, h.currency, h.amount_cents
+		FROM balance_histories h JOIN accounts a ON a.id = h.account_id
+		WHERE h.created_at < ?
+		ORDER BY h.account_id, h.currency, h.created_at DESC, h.id DESC`, end).Scan(&balances).Error; err != nil {
+		return 0, fmt.Errorf("failed to load closing balances: %w", err)
+	}
+
+	var accruals []Accrual
+	for _, b := range balances {
+		accrual := Accrual{AccountID: b.AccountID, Currency: b.Currency, AccrualDate: start, BalanceCents: b.AmountCents}
+		switch {
+		case b.AmountCents > 0:
+			tier := selectTier(tiers, b.AccountType, b.Currency, b.AmountCents)
+			if tier == nil {
+				continue
+			}
+			accrual.Kind = KindCredit
+			accrual.TierID = &tier.ID
+			accrual.RateBps = tier.RateBps
+			accrual.DayCount = tier.DayCount
+		case b.AmountCents < 0:
+			rate, ok := overdraftRates[balance.Key{AccountID: b.AccountID, Currency: b.Currency}]
+			if !ok {
+				rate = defaultOverdraft
+			}
+			accrual.Kind = KindOverdraft
+			accrual.RateBps = rate
+			accrual.DayCount = OverdraftDayCount
+		default:
+			continue
+		}
+		if accrual.RateBps == 0 {
+			continue
+		}
+
+		amount, err := dailyInterest(abs(b.AmountCents), accrual.RateBps, accrual.DayCount, start)
+		if err != nil {
+			return 0, err
+		}
+		accrual.AmountMicroCents = amount
+		accruals = append(accruals, accrual)
+	}
+	if len(accruals) == 0 {
+		return 0, nil
+	}
+
+	res := db.DB.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&accruals, 500)
+	if res.Error != nil {
+		return 0, fmt.Errorf("failed to store interest accruals: %w", res.Error)
+	}
+
+	logger.Info("Interest accrued", map[string]interface{}{
+		"date":     start.Format("2006-01-02"),
+		"accruals": res.RowsAffected,
+	})
+	return res.RowsAffected, nil
+}
+
+// Capitalize books every uncapitalized accrual dated before `before` on its
+// balance: one interest credit per balance, less withholding tax, and one
+// overdraft interest charge per overdrawn balance. Sums under one cent stay
+// accrued and are carried into the next run; the sub-cent remainder of a
+// booked sum is dropped.
+func Capitalize(before time.Time) ([]Capitalization, error) {
+	before = startOfDay(before)
+	println("🏦 Faiz anaparaya ekleniyor, bitiş:", before.Format("2006-01-02"))
+
+	var accruals []Accrual
+	if err := db.DB.Where("capitalization_id IS NULL AND accrual_date < ?", before).
+		Order("account_id, currency, kind, accrual_date").
+		Find(&accruals).Error; err != nil {
+		return nil, fmt.Errorf("failed to load interest accruals: %w", err)
+	}
+
+	type key struct {
+		accountID uint
+		currency  string
+		kind      Kind
+	}
+	groups := map[key][]Accrual{}
+	var keys []key
+	for _, a := range accruals {
+		k := key{a.AccountID, a.Currency, a.Kind}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], a)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].accountID != keys[j].accountID {
+			return keys[i].accountID < keys[j].accountID
+		}
+		if keys[i].currency != keys[j].currency {
+			return keys[i].currency < keys[j].currency
+		}
+		return keys[i].kind < keys[j].kind
+	})
+
+	taxBps := WithholdingRate()
+	var done []Capitalization
+	var failed int
+	for _, k := range keys {
+		group := groups[k]
+		var micro int64
+		ids := make([]uint, 0, len(group))
+		for _, a := range group {
+			micro += a.AmountMicroCents
+			ids = append(ids, a.ID)
+		}
+		gross := micro / MicroCentsPerCent
+		if gross <= 0 {
+			continue
+		}
+		record := Capitalization{
+			AccountID:    k.accountID,
+			Currency:     k.currency,
+			Kind:         k.kind,
+			PeriodStart:  group[0].AccrualDate,
+			PeriodEnd:    group[len(group)-1].AccrualDate,
+			AccrualCount: len(group),
+			GrossCents:   gross,
+			NetCents:     gross,
+		}
+		// markCapitalized stores the capitalization and links its accruals in
+		// the same database transaction as the money movement
+		markCapitalized := func(dbTx *gorm.DB) error {
+			if err := dbTx.Create(&record).Error; err != nil {
+				return fmt.Errorf("failed to store capitalization: %w", err)
+			}
+			res := dbTx.Model(&Accrual{}).
+				Where("id IN ? AND capitalization_id IS NULL", ids).
+				Update("capitalization_id", record.ID)
+			if res.Error != nil {
+				return fmt.Errorf("failed to mark accruals capitalized: %w", res.Error)
+			}
+			if res.RowsAffected != int64(len(ids)) {
+				return fmt.Errorf("accruals were capitalized concurrently")
+			}
+			return nil
+		}
+
+		var err error
+		if k.kind == KindOverdraft {
+			_, err = transaction.ChargeOverdraftInterest(k.accountID, k.currency, gross, func(dbTx *gorm.DB, txModel *transaction.Transaction) error {
+				record.TransactionID = txModel.ID
+				return markCapitalized(dbTx)
+			})
+		} else {
+			record.TaxRateBps = taxBps
+			record.TaxCents = withholding(gross, taxBps)
+			record.NetCents = gross - record.TaxCents
+			_, err = transaction.ApplyInterest(k.accountID, k.currency, gross, record.TaxCents, func(dbTx *gorm.DB, interestTx, taxTx *transaction.Transaction) error {
+				record.TransactionID = interestTx.ID
+				if taxTx != nil {
+					record.TaxTransactionID = &taxTx.ID
+				}
+				return markCapitalized(dbTx)
+			})
+		}
+		if err != nil {
+			failed++
+			logger.Error("Interest capitalization failed", err, map[string]interface{}{
+				"account_id": k.accountID,
+				"currency":   k.currency,
+				"kind":       k.kind,
+			})
+			continue
+		}
+		done = append(done, record)
+	}
+
+	logger.Info("Interest capitalized", map[string]interface{}{
+		"before":          before.Format("2006-01-02"),
+		"capitalizations": len(done),
+		"failed":          failed,
+	})
+	return done, nil
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// ListAccruals returns accruals newest first. Zero or empty filters are
+// ignored; userID limits the result to that user's accounts.
+func ListAccruals(userID, accountID uint, cur string, from, to time.Time, limit int) ([]Accrual, error) {
+	q := scopeAccounts(db.DB.Model(&Accrual{}), userID, accountID)
+	if cur != "" {
+		q = q.Where("currency = ?", cur)
+	}
+	if !from.IsZero() {
+		q = q.Where("accrual_date >= ?", startOfDay(from))
+	}
+	if !to.IsZero() {
+		q = q.Where("accrual_date <= ?", startOfDay(to))
+	}
+	var accruals []Accrual
+	if err := q.Order("accrual_date DESC, id DESC").Limit(limit).Find(&accruals).Error; err != nil {
+		return nil, fmt.Errorf("failed to list interest accruals: %w", err)
+	}
+	return accruals, nil
+}
+
+// ListCapitalizations returns capitalizations newest first, optionally for
+// one user or account
+func ListCapitalizations(userID, accountID uint, limit int) ([]Capitalization, error) {
+	q := scopeAccounts(db.DB.Model(&Capitalization{}), userID, accountID)
+	var caps []Capitalization
+	if err := q.Order("created_at DESC, id DESC").Limit(limit).Find(&caps).Error; err != nil {
+		return nil, fmt.Errorf("failed to list interest capitalizations: %w", err)
+	}
+	return caps, nil
+}
+
+// scopeAccounts filters q by account and by the owner of the account
+func scopeAccounts(q *gorm.DB, userID, accountID uint) *gorm.DB {
+	if userID != 0 {
+		q = q.Where("account_id IN (SELECT id FROM accounts WHERE user_id = ?)", userID)
+	}
+	if accountID != 0 {
+		q = q.Where("account_id = ?", accountID)
+	}
+	return q
+}
@@ -0,0 +1,66 @@
+package ledger
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct{}
+
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// GetTrialBalance returns per-account debit/credit totals
+func (h *Handler) GetTrialBalance(c *gin.Context) {
+	lines, err := TrialBalance()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "mizan hesaplanamadı"})
+		return
+	}
+
+	type totals struct {
+		DebitCents  int64 `json:"debit_cents"`
+		CreditCents int64 `json:"credit_cents"`
+		Balanced    bool  `json:"balanced"`
+	}
+	byCurrency := make(map[string]*totals)
+	balanced := true
+	for _, l := range lines {
+		t, ok := byCurrency[l.Currency]
+		if !ok {
+			t = &totals{}
+			byCurrency[l.Currency] = t
+		}
+		t.DebitCents += l.DebitCents
+		t.CreditCents += l.CreditCents
+	}
+	for _, t := range byCurrency {
+		t.Balanced = t.DebitCents == t.CreditCents
+		balanced = balanced && t.Balanced
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"accounts": lines,
+		"totals":   byCurrency,
+		"balanced": balanced,
+	})
+}
+
+// GetTransactionEntries returns the journal entries posted for a transaction
+func (h *Handler) GetTransactionEntries(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "işlem ID geçersiz"})
+		return
+	}
+
+	entries, err := GetEntriesByTransaction(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "kayıtlar getirilemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,120 @@
+package ledger
+
+import (
+	"fmt"
+	"time"
+)
+
+type AccountType string
+
+const (
+	AccountTypeAsset     AccountType = "asset"
+	AccountTypeLiability AccountType = "liability"
+	AccountTypeIncome    AccountType = "income"
+	AccountTypeExpense   AccountType = "expense"
+)
+
+// Internal bank accounts
+const (
+	AccountCash      = "bank:cash"
+	AccountSuspense  = "bank:suspense"
+	AccountFeeIncome = "bank:fee_income"
+	// AccountFXPosition absorbs both legs of a currency conversion
+	AccountFXPosition      = "bank:fx_position"
+	AccountInterestExpense = "bank:interest_expense"
+	AccountInterestIncome  = "bank:interest_income"
+	// AccountTaxPayable holds withholding tax owed to the tax authority
+	AccountTaxPayable = "bank:tax_payable"
+)
+
+// Account is a general ledger account. Customer accounts are liabilities of
+// the bank; internal accounts hold the other side of every money movement.
+type Account struct {
+	Code      string      `json:"code" gorm:"primaryKey;size:64"`
+	Name      string      `json:"name" gorm:"size:100;not null"`
+	Type      AccountType `json:"type" gorm:"size:20;not null"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+func (Account) TableName() string { return "ledger_accounts" }
+
+// JournalEntry groups the postings of one business event
+type JournalEntry struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	TransactionID *uint     `json:"transaction_id" gorm:"index"`
+	Description   string    `json:"description" gorm:"size:255"`
+	Postings      []Posting `json:"postings" gorm:"foreignKey:EntryID"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Posting is a single debit or credit line against a ledger account
+type Posting struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	EntryID     uint      `json:"entry_id" gorm:"index;not null"`
+	AccountCode string    `json:"account_code" gorm:"size:64;index;not null"`
+	Currency    string    `json:"currency" gorm:"size:3;not null"`
+	DebitCents  int64     `json:"debit_cents" gorm:"not null;default:0;check:debit_cents>=0"`
+	CreditCents int64     `json:"credit_cents" gorm:"not null;default:0;check:credit_cents>=0"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TrialBalanceLine is the aggregated position of one account
+type TrialBalanceLine struct {
+	AccountCode  string      `json:"account_code"`
+	AccountType  AccountType `json:"account_type"`
+	Currency     string      `json:"currency"`
+	DebitCents   int64       `json:"debit_cents"`
+	CreditCents  int64       `json:"credit_cents"`
+	BalanceCents int64       `json:"balance_cents"`
+}
+
+// CustomerAccount returns the ledger account code of a customer account
+func CustomerAccount(accountID uint) string {
+	return fmt.Sprintf("customer:%d", accountID)
+}
+
+// Debit builds a debit posting
+func Debit(accountCode, currency string, cents int64) Posting {
+	return Posting{AccountCode: accountCode, Currency: currency, DebitCents: cents}
+}
+
+// Credit builds a credit posting
+func Credit(accountCode, currency string, cents int64) Posting {
+	return Posting{AccountCode: accountCode, Currency: currency, CreditCents: cents}
+}
+
+// Validate checks that every posting has exactly one positive side and that
+// total debits equal total credits in every currency.
+func Validate(postings []Posting) error {
+	if len(postings) < 2 {
+		return fmt.Errorf("%w: at least two postings are required", ErrUnbalancedEntry)
+	}
+
+	debits := make(map[string]int64)
+	credits := make(map[string]int64)
+	for _, p := range postings {
+		if p.AccountCode == "" {
+			return fmt.Errorf("posting account code is required")
+		}
+		if p.Currency == "" {
+			return fmt.Errorf("posting on %s has no currency", p.AccountCode)
+		}
+		if p.DebitCents < 0 || p.CreditCents < 0 {
+			return fmt.Errorf("posting amounts cannot be negative")
+		}
+		if (p.DebitCents == 0) == (p.CreditCents == 0) {
+			return fmt.Errorf("posting on %s must be either a debit or a credit", p.AccountCode)
+		}
+		debits[p.Currency] += p.DebitCents
+		credits[p.Currency] += p.CreditCents
+	}
+
+	for cur := range debits {
+		if debits[cur] != credits[cur] {
+			return fmt.Errorf("%w: %s debits=%d credits=%d", ErrUnbalancedEntry, cur, debits[cur], credits[cur])
+		}
+	}
+	return nil
+}
+
+var ErrUnbalancedEntry = fmt.Errorf("unbalanced journal entry")
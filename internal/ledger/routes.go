@@ -0,0 +1,21 @@
+package ledger
+
+import (
+	"bankapi/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
+	l := router.Group("/api/v1/ledger")
+
+	// Only use auth middleware if it's provided
+	if authMiddleware != nil {
+		l.Use(authMiddleware, middleware.RequireRoles("admin"))
+	}
+
+	handler := NewHandler()
+
+	l.GET("/trial-balance", handler.GetTrialBalance)
+	l.GET("/transactions/:id/entries", handler.GetTransactionEntries)
+}
@@ -0,0 +1,126 @@
+package ledger
+
+import (
+	"bankapi/internal/db"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var systemAccounts = []Account{
+	{Code: AccountCash, Name: "Bank cash", Type: AccountTypeAsset},
+	{Code: AccountSuspense, Name: "Suspense", Type: AccountTypeLiability},
+	{Code: AccountFeeIncome, Name: "Fee income", Type: AccountTypeIncome},
+	{Code: AccountFXPosition, Name: "FX position", Type: AccountTypeAsset},
+	{Code: AccountInterestExpense, Name: "Interest expense", Type: AccountTypeExpense},
+	{Code: AccountInterestIncome, Name: "Interest income", Type: AccountTypeIncome},
+	{Code: AccountTaxPayable, Name: "Withholding tax payable", Type: AccountTypeLiability},
+}
+
+// SeedSystemAccounts creates the internal bank accounts if they are missing
+func SeedSystemAccounts() error {
+	println("📒 Sistem ledger hesapları kontrol ediliyor...")
+
+	if err := db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&systemAccounts).Error; err != nil {
+		println("❌ Sistem ledger hesapları oluşturulamadı:", err.Error())
+		return fmt.Errorf("failed to seed ledger accounts: %w", err)
+	}
+
+	println("✅ Sistem ledger hesapları hazır")
+	return nil
+}
+
+// PostTx validates and writes a balanced journal entry inside tx. An
+// unbalanced entry is rejected with ErrUnbalancedEntry so the caller's
+// transaction rolls back.
+func PostTx(tx *gorm.DB, transactionID *uint, description string, postings ...Posting) (*JournalEntry, error) {
+	println("📒 Journal entry yazılıyor:", description, "posting sayısı:", len(postings))
+
+	if err := Validate(postings); err != nil {
+		println("❌ Journal entry reddedildi:", err.Error())
+		return nil, err
+	}
+
+	if err := ensureAccounts(tx, postings); err != nil {
+		return nil, err
+	}
+
+	entry := &JournalEntry{
+		TransactionID: transactionID,
+		Description:   description,
+		Postings:      postings,
+		CreatedAt:     time.Now(),
+	}
+	if err := tx.Create(entry).Error; err != nil {
+		println("❌ Journal entry kaydedilemedi:", err.Error())
+		return nil, fmt.Errorf("failed to create journal entry: %w", err)
+	}
+
+	println("✅ Journal entry kaydedildi, ID:", entry.ID)
+	return entry, nil
+}
+
+// ensureAccounts opens customer ledger accounts on first use
+func ensureAccounts(tx *gorm.DB, postings []Posting) error {
+	var accounts []Account
+	seen := make(map[string]struct{})
+	for _, p := range postings {
+		if _, ok := seen[p.AccountCode]; ok {
+			continue
+		}
+		seen[p.AccountCode] = struct{}{}
+		if strings.HasPrefix(p.AccountCode, "customer:") {
+			accounts = append(accounts, Account{Code: p.AccountCode, Name: "Customer account " + strings.TrimPrefix(p.AccountCode, "customer:"), Type: AccountTypeLiability})
+		}
+	}
+	if len(accounts) > 0 {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&accounts).Error; err != nil {
+			return fmt.Errorf("failed to open ledger accounts: %w", err)
+		}
+	}
+
+	var count int64
+	codes := make([]string, 0, len(seen))
+	for code := range seen {
+		codes = append(codes, code)
+	}
+	if err := tx.Model(&Account{}).Where("code IN ?", codes).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check ledger accounts: %w", err)
+	}
+	if int(count) != len(codes) {
+		return fmt.Errorf("unknown ledger account in %v", codes)
+	}
+	return nil
+}
+
+// TrialBalance sums all postings per account and currency. Within each
+// currency total debits always equal total credits when the ledger is consistent.
+func TrialBalance() ([]TrialBalanceLine, error) {
+	var lines []TrialBalanceLine
+	err := db.DB.Table("postings").
+		Select("postings.account_code, ledger_accounts.type AS account_type, postings.currency, SUM(postings.debit_cents) AS debit_cents, SUM(postings.credit_cents) AS credit_cents").
+		Joins("JOIN ledger_accounts ON ledger_accounts.code = postings.account_code").
+		Group("postings.account_code, ledger_accounts.type, postings.currency").
+		Order("postings.currency, postings.account_code").
+		Scan(&lines).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute trial balance: %w", err)
+	}
+
+	for i := range lines {
+		lines[i].BalanceCents = lines[i].DebitCents - lines[i].CreditCents
+	}
+	return lines, nil
+}
+
+// GetEntriesByTransaction returns the journal entries of a transaction
+func GetEntriesByTransaction(transactionID uint) ([]JournalEntry, error) {
+	var entries []JournalEntry
+	if err := db.DB.Preload("Postings").Where("transaction_id = ?", transactionID).Order("id").Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to load journal entries: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,90 @@
+package limits
+
+import (
+	"bankapi/internal/audit"
+	"bankapi/internal/middleware"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct{}
+
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// GetMyLimits returns the caller's limits and what is left of them
+func (h *Handler) GetMyLimits(c *gin.Context) {
+	u, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Kimlik doğrulaması gerekli"})
+		return
+	}
+	statuses, err := GetStatus(u.ID, u.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "limitler getirilemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user_id": u.ID, "role": u.Role, "limits": statuses})
+}
+
+// GetUserLimits returns the overrides of a user
+func (h *Handler) GetUserLimits(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	overrides, err := GetUserLimits(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "limitler getirilemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, overrides)
+}
+
+// SetUserLimit creates or replaces an override
+func (h *Handler) SetUserLimit(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	var req SetUserLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	override, err := SetUserLimit(userID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "limit kaydedilemedi"})
+		return
+	}
+	audit.Log("user_limit", fmt.Sprintf("%d", userID), "set", req.TransactionType)
+	c.JSON(http.StatusOK, override)
+}
+
+// DeleteUserLimit drops an override
+func (h *Handler) DeleteUserLimit(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	txType := c.Param("type")
+	if err := DeleteUserLimit(userID, txType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "limit silinemedi"})
+		return
+	}
+	audit.Log("user_limit", fmt.Sprintf("%d", userID), "delete", txType)
+	c.Status(http.StatusNoContent)
+}
+
+func parseUserID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kullanıcı ID geçersiz"})
+		return 0, false
+	}
+	return uint(id), true
+}
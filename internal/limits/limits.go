@@ -0,0 +1,128 @@
+package limits
+
+import (
+	"bankapi/internal/currency"
+	"fmt"
+	"time"
+)
+
+type Period string
+
+const (
+	PeriodTransaction Period = "per_transaction"
+	PeriodDaily       Period = "daily"
+	PeriodMonthly     Period = "monthly"
+)
+
+// Currency is the currency every limit and usage figure is expressed in.
+// Transactions in other currencies are converted at the mid rate.
+const Currency = currency.DefaultCurrency
+
+// Limited transaction types. Credits are money coming in and are not capped.
+var TransactionTypes = []string{"debit", "transfer"}
+
+// Limit holds the caps for one transaction type. Zero means unlimited.
+type Limit struct {
+	TransactionType     string `json:"transaction_type"`
+	PerTransactionCents int64  `json:"per_transaction_cents"`
+	DailyCents          int64  `json:"daily_cents"`
+	MonthlyCents        int64  `json:"monthly_cents"`
+}
+
+// RoleDefaults are the limits applied when a user has no override. Unknown
+// roles fall back to the "user" defaults.
+var RoleDefaults = map[string]map[string]Limit{
+	"user": {
+		"debit":    {TransactionType: "debit", PerTransactionCents: 2_500_000, DailyCents: 5_000_000, MonthlyCents: 25_000_000},
+		"transfer": {TransactionType: "transfer", PerTransactionCents: 5_000_000, DailyCents: 10_000_000, MonthlyCents: 50_000_000},
+	},
+	"moderator": {
+		"debit":    {TransactionType: "debit", PerTransactionCents: 5_000_000, DailyCents: 10_000_000, MonthlyCents: 50_000_000},
+		"transfer": {TransactionType: "transfer", PerTransactionCents: 10_000_000, DailyCents: 20_000_000, MonthlyCents: 100_000_000},
+	},
+	"admin": {
+		"debit":    {TransactionType: "debit", PerTransactionCents: 25_000_000, DailyCents: 50_000_000, MonthlyCents: 250_000_000},
+		"transfer": {TransactionType: "transfer", PerTransactionCents: 50_000_000, DailyCents: 100_000_000, MonthlyCents: 500_000_000},
+	},
+}
+
+// UserLimit overrides a user's role defaults for one transaction type. A nil
+// field keeps the role default.
+type UserLimit struct {
+	ID                  uint      `json:"id" gorm:"primaryKey"`
+	UserID              uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_user_limits_user_type"`
+	TransactionType     string    `json:"transaction_type" gorm:"size:20;not null;uniqueIndex:idx_user_limits_user_type"`
+	PerTransactionCents *int64    `json:"per_transaction_cents"`
+	DailyCents          *int64    `json:"daily_cents"`
+	MonthlyCents        *int64    `json:"monthly_cents"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+func (UserLimit) TableName() string { return "user_limits" }
+
+type SetUserLimitRequest struct {
+	TransactionType     string `json:"transaction_type" binding:"required,oneof=debit transfer"`
+	PerTransactionCents *int64 `json:"per_transaction_cents" binding:"omitempty,gte=0"`
+	DailyCents          *int64 `json:"daily_cents" binding:"omitempty,gte=0"`
+	MonthlyCents        *int64 `json:"monthly_cents" binding:"omitempty,gte=0"`
+}
+
+// Apply returns l with the non-nil fields of the override
+func (o UserLimit) Apply(l Limit) Limit {
+	if o.PerTransactionCents != nil {
+		l.PerTransactionCents = *o.PerTransactionCents
+	}
+	if o.DailyCents != nil {
+		l.DailyCents = *o.DailyCents
+	}
+	if o.MonthlyCents != nil {
+		l.MonthlyCents = *o.MonthlyCents
+	}
+	return l
+}
+
+// Status is a limit together with what has been used in the current periods
+type Status struct {
+	Limit
+	Currency              string `json:"currency"`
+	DailyUsedCents        int64  `json:"daily_used_cents"`
+	MonthlyUsedCents      int64  `json:"monthly_used_cents"`
+	DailyRemainingCents   *int64 `json:"daily_remaining_cents"`
+	MonthlyRemainingCents *int64 `json:"monthly_remaining_cents"`
+}
+
+// ExceededError is returned when a transaction would break one of the limits
+type ExceededError struct {
+	TransactionType string
+	Period          Period
+	LimitCents      int64
+	UsedCents       int64
+	AmountCents     int64
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s %s limit exceeded: limit %d, used %d, requested %d %s",
+		e.TransactionType, e.Period, e.LimitCents, e.UsedCents, e.AmountCents, Currency)
+}
+
+// RemainingCents is what could still be spent in the period
+func (e *ExceededError) RemainingCents() int64 {
+	return remaining(e.LimitCents, e.UsedCents)
+}
+
+func remaining(limit, used int64) int64 {
+	if used >= limit {
+		return 0
+	}
+	return limit - used
+}
+
+// startOfDay and startOfMonth bound the daily and monthly windows
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
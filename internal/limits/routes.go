@@ -0,0 +1,25 @@
+package limits
+
+import (
+	"bankapi/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
+	l := router.Group("/api/v1/limits")
+
+	// Only use auth middleware if it's provided
+	if authMiddleware != nil {
+		l.Use(authMiddleware)
+	}
+
+	handler := NewHandler()
+
+	l.GET("/me", handler.GetMyLimits)
+
+	admin := l.Group("/users", middleware.RequireRoles("admin"))
+	admin.GET("/:id", handler.GetUserLimits)
+	admin.PUT("/:id", handler.SetUserLimit)
+	admin.DELETE("/:id/:type", handler.DeleteUserLimit)
+}
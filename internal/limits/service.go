@@ -0,0 +1,198 @@
+package limits
+
+import (
+	"bankapi/internal/currency"
+	"bankapi/internal/db"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// usageLockClass namespaces the advisory locks that serialise the limit
+// checks of a user
+const usageLockClass = 4204
+
+// EffectiveTx returns the role default for txType with the user's override
+// applied on top.
+func EffectiveTx(tx *gorm.DB, userID uint, role, txType string) (Limit, error) {
+	defaults, ok := RoleDefaults[role]
+	if !ok {
+		defaults = RoleDefaults["user"]
+	}
+	l, ok := defaults[txType]
+	if !ok {
+		l = Limit{TransactionType: txType}
+	}
+
+	var override UserLimit
+	err := tx.Where("user_id = ? AND transaction_type = ?", userID, txType).First(&override).Error
+	if err == nil {
+		return override.Apply(l), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return Limit{}, fmt.Errorf("failed to load user limit: %w", err)
+	}
+	return l, nil
+}
+
+// UsageTx sums what userID has sent with txType since the given time, in the
+// limits currency. Failed, reversed and refunded amounts do not count. A
+// scheduled transfer counts from its value date, not from when it was
+// booked. excludeID skips the transaction currently being checked.
+func UsageTx(tx *gorm.DB, userID uint, txType string, since time.Time, excludeID uint) (int64, error) {
+	var rows []struct {
+		Currency string
+		Total    int64
+	}
+	if err := tx.Table("transactions").
+		Select("currency, COALESCE(SUM(amount_cents - refunded_cents), 0) AS total").
+		Where("from_user_id = ? AND type = ? AND COALESCE(execute_at, created_at) >= ? AND COALESCE(execute_at, created_at) <= ? AND id <> ?",
+			userID, txType, since, time.Now(), excludeID).
+		Where("status IN ?", []string{"pending", "pending_review", "completed", "partially_refunded"}).
+		Group("currency").
+		Scan(&rows).Error; err != nil {
+		return 0, fmt.Errorf("failed to load limit usage: %w", err)
+	}
+
+	var total int64
+	for _, r := range rows {
+		converted, err := toLimitCurrency(r.Total, r.Currency)
+		if err != nil {
+			return 0, err
+		}
+		total += converted
+	}
+	return total, nil
+}
+
+// CheckTx returns an *ExceededError if sending amount of cur would break the
+// per-transaction, daily or monthly limit of the user. The usage check holds
+// a per-user lock until tx ends, so concurrent debits from different
+// accounts or currencies of the same user cannot both pass it.
+func CheckTx(tx *gorm.DB, userID uint, role, txType, cur string, amount int64, excludeID uint) error {
+	l, err := EffectiveTx(tx, userID, role, txType)
+	if err != nil {
+		return err
+	}
+	converted, err := toLimitCurrency(amount, cur)
+	if err != nil {
+		return err
+	}
+
+	if l.PerTransactionCents > 0 && converted > l.PerTransactionCents {
+		println("⛔ İşlem başı limit aşıldı, kullanıcı ID:", userID)
+		return &ExceededError{TransactionType: txType, Period: PeriodTransaction, LimitCents: l.PerTransactionCents, AmountCents: converted}
+	}
+
+	if l.DailyCents <= 0 && l.MonthlyCents <= 0 {
+		return nil
+	}
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", usageLockClass, int32(userID)).Error; err != nil {
+		return fmt.Errorf("failed to lock limit usage: %w", err)
+	}
+
+	now := time.Now()
+	windows := []struct {
+		period Period
+		limit  int64
+		since  time.Time
+	}{
+		{PeriodDaily, l.DailyCents, startOfDay(now)},
+		{PeriodMonthly, l.MonthlyCents, startOfMonth(now)},
+	}
+	for _, w := range windows {
+		if w.limit <= 0 {
+			continue
+		}
+		used, err := UsageTx(tx, userID, txType, w.since, excludeID)
+		if err != nil {
+			return err
+		}
+		if used+converted > w.limit {
+			println("⛔", string(w.period), "limit aşıldı, kullanıcı ID:", userID, "kullanılan:", used)
+			return &ExceededError{TransactionType: txType, Period: w.period, LimitCents: w.limit, UsedCents: used, AmountCents: converted}
+		}
+	}
+	return nil
+}
+
+// GetStatus returns every limit of the user with the current usage
+func GetStatus(userID uint, role string) ([]Status, error) {
+	now := time.Now()
+	statuses := make([]Status, 0, len(TransactionTypes))
+	for _, txType := range TransactionTypes {
+		l, err := EffectiveTx(db.DB, userID, role, txType)
+		if err != nil {
+			return nil, err
+		}
+		daily, err := UsageTx(db.DB, userID, txType, startOfDay(now), 0)
+		if err != nil {
+			return nil, err
+		}
+		monthly, err := UsageTx(db.DB, userID, txType, startOfMonth(now), 0)
+		if err != nil {
+			return nil, err
+		}
+
+		s := Status{Limit: l, Currency: Currency, DailyUsedCents: daily, MonthlyUsedCents: monthly}
+		if l.DailyCents > 0 {
+			r := remaining(l.DailyCents, daily)
+			s.DailyRemainingCents = &r
+		}
+		if l.MonthlyCents > 0 {
+			r := remaining(l.MonthlyCents, monthly)
+			s.MonthlyRemainingCents = &r
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+func GetUserLimits(userID uint) ([]UserLimit, error) {
+	var overrides []UserLimit
+	if err := db.DB.Where("user_id = ?", userID).Order("transaction_type").Find(&overrides).Error; err != nil {
+		return nil, fmt.Errorf("failed to load user limits: %w", err)
+	}
+	return overrides, nil
+}
+
+// SetUserLimit creates or replaces the user's override for a transaction type
+func SetUserLimit(userID uint, req SetUserLimitRequest) (*UserLimit, error) {
+	override := UserLimit{
+		UserID:              userID,
+		TransactionType:     req.TransactionType,
+		PerTransactionCents: req.PerTransactionCents,
+		DailyCents:          req.DailyCents,
+		MonthlyCents:        req.MonthlyCents,
+	}
+	if err := db.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "transaction_type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"per_transaction_cents", "daily_cents", "monthly_cents", "updated_at"}),
+	}).Create(&override).Error; err != nil {
+		return nil, fmt.Errorf("failed to save user limit: %w", err)
+	}
+	return &override, nil
+}
+
+// DeleteUserLimit removes the override so the role defaults apply again
+func DeleteUserLimit(userID uint, txType string) error {
+	if err := db.DB.Where("user_id = ? AND transaction_type = ?", userID, txType).Delete(&UserLimit{}).Error; err != nil {
+		return fmt.Errorf("failed to delete user limit: %w", err)
+	}
+	return nil
+}
+
+func toLimitCurrency(amount int64, cur string) (int64, error) {
+	if cur == "" || cur == Currency {
+		return amount, nil
+	}
+	converted, err := currency.Default().Convert(float64(amount), cur, Currency)
+	if err != nil {
+		return 0, fmt.Errorf("failed to convert %s to %s: %w", cur, Currency, err)
+	}
+	return int64(math.Round(converted)), nil
+}
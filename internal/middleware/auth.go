@@ -15,7 +15,7 @@ import (
 )
 
 const (
-	ContextUserKey = "currentUser"
+	ContextUserKey = user.ContextKey
 )
 
 func getBearerToken(header string) (string, error) {
@@ -83,3 +83,8 @@ func AuthRequired(cfg *config.Config) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// CurrentUser returns the authenticated user stored by AuthRequired
+func CurrentUser(c *gin.Context) (user.User, bool) {
+	return user.Current(c)
+}
@@ -0,0 +1,81 @@
+package reconciliation
+
+import (
+	"bankapi/internal/audit"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct{}
+
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// GetReport returns the discrepancies of ?run_id, or of the latest run
+func (h *Handler) GetReport(c *gin.Context) {
+	var (
+		run *Run
+		err error
+	)
+	if v := c.Query("run_id"); v != "" {
+		id, perr := strconv.ParseUint(v, 10, 64)
+		if perr != nil || id == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "run_id geçersiz"})
+			return
+		}
+		run, err = GetRun(uint(id))
+	} else {
+		run, err = LatestRun()
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+// ListRuns returns past runs without their discrepancies
+func (h *Handler) ListRuns(c *gin.Context) {
+	limit := 100
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit geçersiz"})
+			return
+		}
+		limit = n
+	}
+	runs, err := ListRuns(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "mutabakat çalışmaları getirilemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, runs)
+}
+
+// Run reconciles every balance now and returns the report
+func (h *Handler) Run(c *gin.Context) {
+	run, err := Reconcile(TriggerManual)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	audit.Log("reconciliation", fmt.Sprintf("%d", run.ID), "run", fmt.Sprintf("balances=%d discrepancies=%d", run.BalancesChecked, run.DiscrepancyCount))
+	c.JSON(http.StatusOK, run)
+}
+
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrRunNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "mutabakat çalışması bulunamadı"})
+	case errors.Is(err, ErrRunInProgress):
+		c.JSON(http.StatusConflict, gin.H{"error": "mutabakat zaten çalışıyor"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
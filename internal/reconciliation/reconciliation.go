@@ -0,0 +1,62 @@
+package reconciliation
+
+import (
+	"errors"
+	"time"
+)
+
+type Trigger string
+type RunStatus string
+type Kind string
+
+const (
+	TriggerScheduled Trigger = "scheduled"
+	TriggerManual    Trigger = "manual"
+
+	RunStatusRunning   RunStatus = "running"
+	RunStatusCompleted RunStatus = "completed"
+	RunStatusFailed    RunStatus = "failed"
+
+	// KindBalance is a balance that differs from its booked transactions
+	KindBalance Kind = "balance"
+	// KindHistory is a latest balance history entry that differs from the balance
+	KindHistory Kind = "history"
+)
+
+var (
+	ErrRunNotFound   = errors.New("reconciliation run not found")
+	ErrRunInProgress = errors.New("a reconciliation run is already in progress")
+)
+
+// Run is one pass over every account balance
+type Run struct {
+	ID               uint          `json:"id" gorm:"primaryKey"`
+	Trigger          Trigger       `json:"trigger" gorm:"size:20;not null"`
+	Status           RunStatus     `json:"status" gorm:"size:20;not null;index"`
+	BalancesChecked  int           `json:"balances_checked"`
+	DiscrepancyCount int           `json:"discrepancy_count"`
+	Error            string        `json:"error,omitempty" gorm:"type:text"`
+	StartedAt        time.Time     `json:"started_at" gorm:"index"`
+	FinishedAt       *time.Time    `json:"finished_at,omitempty"`
+	Discrepancies    []Discrepancy `json:"discrepancies,omitempty" gorm:"foreignKey:RunID"`
+}
+
+func (Run) TableName() string { return "reconciliation_runs" }
+
+// Discrepancy is one balance that did not reconcile. ExpectedCents is the
+// sum of the account's booked transactions in the currency; DriftCents is
+// what the checked figure is off by.
+type Discrepancy struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	RunID         uint      `json:"run_id" gorm:"index;not null"`
+	AccountID     uint      `json:"account_id" gorm:"index;not null"`
+	Currency      string    `json:"currency" gorm:"size:3;not null"`
+	Kind          Kind      `json:"kind" gorm:"size:20;not null"`
+	ExpectedCents int64     `json:"expected_cents"`
+	BalanceCents  int64     `json:"balance_cents"`
+	HistoryCents  *int64    `json:"history_cents,omitempty"`
+	DriftCents    int64     `json:"drift_cents"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (Discrepancy) TableName() string { return "reconciliation_discrepancies" }
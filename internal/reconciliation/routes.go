@@ -0,0 +1,22 @@
+package reconciliation
+
+import (
+	"bankapi/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
+	r := router.Group("/api/v1/admin/reconciliation")
+
+	// Only use auth middleware if it's provided
+	if authMiddleware != nil {
+		r.Use(authMiddleware, middleware.RequireRoles("admin"))
+	}
+
+	handler := NewHandler()
+
+	r.GET("", handler.GetReport)
+	r.GET("/runs", handler.ListRuns)
+	r.POST("/run", handler.Run)
+}
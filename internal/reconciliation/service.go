@@ -0,0 +1,247 @@
+package reconciliation
+
+import (
+	"bankapi/internal/balance"
+	"bankapi/internal/db"
+	"bankapi/internal/logger"
+	"bankapi/internal/transaction"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// bookedStatuses are the statuses of transactions that moved money. A
+// reversed or refunded original still booked its amount; the compensation
+// is a transaction of its own.
+var bookedStatuses = []transaction.TransactionStatus{
+	transaction.TransactionStatusCompleted,
+	transaction.TransactionStatusReversed,
+	transaction.TransactionStatusPartiallyRefunded,
+	transaction.TransactionStatusRefunded,
+}
+
+var running atomic.Bool
+
+// figures are the three views of one balance that must agree
+type figures struct {
+	expected int64
+	balance  int64
+	history  *int64
+}
+
+// Reconcile recomputes every account balance from its booked transactions
+// and compares it with the stored balance and the latest balance history
+// entry. All three are read from one repeatable-read snapshot so transfers
+// running meanwhile cannot show up as drift. The run and every discrepancy
+// are stored; only one run happens at a time.
+func Reconcile(trigger Trigger) (*Run, error) {
+	if !running.CompareAndSwap(false, true) {
+		return nil, ErrRunInProgress
+	}
+	defer running.Store(false)
+
+	println("🔍 Mutabakat başlatılıyor, tetikleyen:", string(trigger))
+
+	run := &Run{Trigger: trigger, Status: RunStatusRunning, StartedAt: time.Now()}
+	if err := db.DB.Create(run).Error; err != nil {
+		return nil, fmt.Errorf("failed to create reconciliation run: %w", err)
+	}
+
+	var found map[balance.Key]*figures
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		found, err = collect(tx)
+		return err
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		finish(run, nil, err)
+		return run, err
+	}
+
+	keys := make([]balance.Key, 0, len(found))
+	for k := range found {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].AccountID != keys[j].AccountID {
+			return keys[i].AccountID < keys[j].AccountID
+		}
+		return keys[i].Currency < keys[j].Currency
+	})
+
+	var discrepancies []Discrepancy
+	for _, k := range keys {
+		f := found[k]
+		d := Discrepancy{
+			RunID:         run.ID,
+			AccountID:     k.AccountID,
+			Currency:      k.Currency,
+			ExpectedCents: f.expected,
+			BalanceCents:  f.balance,
+			HistoryCents:  f.history,
+		}
+		if f.balance != f.expected {
+			d.Kind = KindBalance
+			d.DriftCents = f.balance - f.expected
+			discrepancies = append(discrepancies, d)
+		}
+		var history int64
+		if f.history != nil {
+			history = *f.history
+		}
+		if history != f.balance {
+			d.Kind = KindHistory
+			d.DriftCents = history - f.balance
+			discrepancies = append(discrepancies, d)
+		}
+	}
+	run.BalancesChecked = len(keys)
+
+	err = finish(run, discrepancies, nil)
+	if err != nil {
+		return run, err
+	}
+
+	if len(discrepancies) > 0 {
+		logger.Error("Reconciliation found discrepancies", fmt.Errorf("%d discrepancies", len(discrepancies)), map[string]interface{}{
+			"run_id":           run.ID,
+			"balances_checked": run.BalancesChecked,
+		})
+	} else {
+		logger.Info("Reconciliation completed", map[string]interface{}{
+			"run_id":           run.ID,
+			"balances_checked": run.BalancesChecked,
+		})
+	}
+	println("✅ Mutabakat tamamlandı, fark sayısı:", len(discrepancies))
+	return run, nil
+}
+
+// collect reads the booked total, the stored balance and the latest history
+// entry of every balance that has any of them
+func collect(tx *gorm.DB) (map[balance.Key]*figures, error) {
+	found := map[balance.Key]*figures{}
+	get := func(accountID uint, currency string) *figures {
+		k := balance.Key{AccountID: accountID, Currency: currency}
+		f, ok := found[k]
+		if !ok {
+			f = &figures{}
+			found[k] = f
+		}
+		return f
+	}
+
+	type row struct {
+		AccountID   uint
+		Currency    string
+		AmountCents int64
+	}
+
+	// Money in is credited in the credit currency (the converted leg of an
+	// FX transfer), money out is debited in the transaction currency
+	var booked []row
+	if err := tx.Raw(`SELECT account_id, currency, SUM(amount_cents) AS amount_cents FROM (
+			SELECT to_account_id AS account_id,
+				COALESCE(NULLIF(to_currency, ''), currency) AS currency,
+				CASE WHEN COALESCE(NULLIF(to_currency, ''), currency) <> currency THEN to_amount_cents ELSE amount_cents END AS amount_cents
+			FROM transactions WHERE to_account_id IS NOT NULL AND status IN ?
+			UNION ALL
+			SELECT from_account_id, currency, -amount_cents
+			FROM transactions WHERE from_account_id IS NOT NULL AND status IN ?
+		) movements GROUP BY account_id, currency`, bookedStatuses, bookedStatuses).
+		Scan(&booked).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum transactions: %w", err)
+	}
+	for _, r := range booked {
+		get(r.AccountID, r.Currency).expected = r.AmountCents
+	}
+
+	var balances []balance.Balance
+	if err := tx.Find(&balances).Error; err != nil {
+		return nil, fmt.Errorf("failed to load balances: %w", err)
+	}
+	for _, b := range balances {
+		get(b.AccountID, b.Currency).balance = b.AmountCents
+	}
+
+	var latest []row
+	if err := tx.Raw(`SELECT DISTINCT ON (account_id, currency) account_id, currency, amount_cents
+		FROM balance_histories
+		ORDER BY account_id, currency, created_at DESC, id DESC`).
+		Scan(&latest).Error; err != nil {
+		return nil, fmt.Errorf("failed to load balance history: %w", err)
+	}
+	for _, r := range latest {
+		amount := r.AmountCents
+		get(r.AccountID, r.Currency).history = &amount
+	}
+	return found, nil
+}
+
+// finish stores the outcome of run together with its discrepancies
+func finish(run *Run, discrepancies []Discrepancy, cause error) error {
+	now := time.Now()
+	run.FinishedAt = &now
+	run.Status = RunStatusCompleted
+	if cause != nil {
+		run.Status = RunStatusFailed
+		run.Error = cause.Error()
+		logger.Error("Reconciliation failed", cause, map[string]interface{}{"run_id": run.ID})
+	}
+	run.DiscrepancyCount = len(discrepancies)
+	run.Discrepancies = discrepancies
+
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if len(discrepancies) > 0 {
+			if err := tx.CreateInBatches(&discrepancies, 500).Error; err != nil {
+				return fmt.Errorf("failed to store discrepancies: %w", err)
+			}
+		}
+		if err := tx.Omit("Discrepancies").Save(run).Error; err != nil {
+			return fmt.Errorf("failed to update reconciliation run: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetRun returns a run with its discrepancies
+func GetRun(id uint) (*Run, error) {
+	var run Run
+	err := db.DB.Preload("Discrepancies", func(q *gorm.DB) *gorm.DB {
+		return q.Order("account_id, currency, kind")
+	}).First(&run, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRunNotFound
+		}
+		return nil, fmt.Errorf("failed to load reconciliation run: %w", err)
+	}
+	return &run, nil
+}
+
+// LatestRun returns the most recent finished run with its discrepancies
+func LatestRun() (*Run, error) {
+	var run Run
+	err := db.DB.Where("status <> ?", RunStatusRunning).Order("id DESC").First(&run).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRunNotFound
+		}
+		return nil, fmt.Errorf("failed to load reconciliation run: %w", err)
+	}
+	return GetRun(run.ID)
+}
+
+// ListRuns returns runs newest first without their discrepancies
+func ListRuns(limit int) ([]Run, error) {
+	var runs []Run
+	if err := db.DB.Order("id DESC").Limit(limit).Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list reconciliation runs: %w", err)
+	}
+	return runs, nil
+}
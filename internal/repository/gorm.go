@@ -2,6 +2,7 @@ package repository
 
 import (
 	"bankapi/internal/balance"
+	"bankapi/internal/currency"
 	"bankapi/internal/db"
 	"bankapi/internal/transaction"
 	"bankapi/internal/user"
@@ -43,16 +44,16 @@ func (GormTransactionRepo) ListLatest(limit int) ([]transaction.Transaction, err
 
 type GormBalanceRepo struct{}
 
-func (GormBalanceRepo) GetOrCreate(userID uint) (balance.Balance, error) {
+func (GormBalanceRepo) GetOrCreate(accountID uint) (balance.Balance, error) {
 	var b balance.Balance
-	if err := db.DB.FirstOrCreate(&b, balance.Balance{UserID: userID}).Error; err != nil {
+	if err := db.DB.FirstOrCreate(&b, balance.Balance{AccountID: accountID, Currency: currency.DefaultCurrency}).Error; err != nil {
 		return balance.Balance{}, err
 	}
 	return b, nil
 }
 func (GormBalanceRepo) Save(bal *balance.Balance) error { return db.DB.Save(bal).Error }
-func (GormBalanceRepo) History(userID uint, limit int) ([]balance.BalanceHistory, error) {
+func (GormBalanceRepo) History(accountID uint, limit int) ([]balance.BalanceHistory, error) {
 	var hist []balance.BalanceHistory
-	err := db.DB.Where("user_id = ?", userID).Order("id DESC").Limit(limit).Find(&hist).Error
+	err := db.DB.Where("account_id = ?", accountID).Order("sequence DESC").Limit(limit).Find(&hist).Error
 	return hist, err
 }
@@ -24,7 +24,7 @@ type TransactionRepository interface {
 
 // BalanceRepository defines storage operations for balances
 type BalanceRepository interface {
-	GetOrCreate(userID uint) (balance.Balance, error)
+	GetOrCreate(accountID uint) (balance.Balance, error)
 	Save(b *balance.Balance) error
-	History(userID uint, limit int) ([]balance.BalanceHistory, error)
+	History(accountID uint, limit int) ([]balance.BalanceHistory, error)
 }
@@ -16,12 +16,12 @@ func NewHandler(scheduler *Scheduler) *Handler {
 
 // ScheduleTransactionRequest represents the request to schedule a transaction
 type ScheduleTransactionRequest struct {
-	FromUserID string                 `json:"from_user_id" binding:"required"`
-	ToUserID   string                 `json:"to_user_id" binding:"required"`
-	Amount     float64                `json:"amount" binding:"required,gt=0"`
-	Type       string                 `json:"type" binding:"required"`
-	Schedule   string                 `json:"schedule" binding:"required"` // Cron expression
-	Metadata   map[string]interface{} `json:"metadata"`
+	FromAccountID string                 `json:"from_account_id" binding:"required"`
+	ToAccountID   string                 `json:"to_account_id" binding:"required"`
+	Amount        float64                `json:"amount" binding:"required,gt=0"`
+	Type          string                 `json:"type" binding:"required"`
+	Schedule      string                 `json:"schedule" binding:"required"` // Cron expression
+	Metadata      map[string]interface{} `json:"metadata"`
 }
 
 // ScheduleTransaction schedules a new transaction
@@ -39,13 +39,13 @@ func (h *Handler) ScheduleTransaction(c *gin.Context) {
 	}
 
 	st := &ScheduledTransaction{
-		FromUserID: req.FromUserID,
-		ToUserID:   req.ToUserID,
-		Amount:     req.Amount,
-		Type:       req.Type,
-		Schedule:   req.Schedule,
-		Status:     "pending",
-		Metadata:   req.Metadata,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Type:          req.Type,
+		Schedule:      req.Schedule,
+		Status:        "pending",
+		Metadata:      req.Metadata,
 	}
 
 	if err := h.scheduler.ScheduleTransaction(st); err != nil {
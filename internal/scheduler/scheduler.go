@@ -12,22 +12,23 @@ import (
 
 // ScheduledTransaction represents a transaction that will be executed at a specific time
 type ScheduledTransaction struct {
-	ID         string                 `json:"id"`
-	FromUserID string                 `json:"from_user_id"`
-	ToUserID   string                 `json:"to_user_id"`
-	Amount     float64                `json:"amount"`
-	Type       string                 `json:"type"`
-	Schedule   string                 `json:"schedule"` // Cron expression
-	Status     string                 `json:"status"`   // pending, completed, failed
-	CreatedAt  time.Time              `json:"created_at"`
-	ExecuteAt  time.Time              `json:"execute_at"`
-	Metadata   map[string]interface{} `json:"metadata"`
+	ID            string                 `json:"id"`
+	FromAccountID string                 `json:"from_account_id"`
+	ToAccountID   string                 `json:"to_account_id"`
+	Amount        float64                `json:"amount"`
+	Type          string                 `json:"type"`
+	Schedule      string                 `json:"schedule"` // Cron expression
+	Status        string                 `json:"status"`   // pending, completed, failed
+	CreatedAt     time.Time              `json:"created_at"`
+	ExecuteAt     time.Time              `json:"execute_at"`
+	Metadata      map[string]interface{} `json:"metadata"`
 }
 
 // Scheduler manages scheduled transactions
 type Scheduler struct {
 	cron     *cron.Cron
 	entries  map[string]cron.EntryID
+	jobs     map[string]cron.EntryID
 	mutex    sync.RWMutex
 	eventBus events.EventBus
 }
@@ -37,6 +38,7 @@ func NewScheduler(eventBus events.EventBus) *Scheduler {
 	return &Scheduler{
 		cron:     cron.New(cron.WithSeconds()),
 		entries:  make(map[string]cron.EntryID),
+		jobs:     make(map[string]cron.EntryID),
 		eventBus: eventBus,
 	}
 }
@@ -97,6 +99,32 @@ func (s *Scheduler) ScheduleTransaction(st *ScheduledTransaction) error {
 	return nil
 }
 
+// AddJob registers a recurring maintenance job under name, using a cron
+// expression with seconds (e.g. "0 * * * * *" for every minute).
+func (s *Scheduler) AddJob(name, spec string, job func()) error {
+	println("⏰ Periyodik iş kaydediliyor:", name, "zamanlama:", spec)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.jobs[name]; exists {
+		return fmt.Errorf("job already registered: %s", name)
+	}
+
+	entryID, err := s.cron.AddFunc(spec, func() {
+		println("🔁 Periyodik iş çalışıyor:", name)
+		job()
+	})
+	if err != nil {
+		println("❌ Periyodik iş kaydedilemedi:", err.Error())
+		return fmt.Errorf("failed to add job %s: %w", name, err)
+	}
+
+	s.jobs[name] = entryID
+	log.Printf("Registered job %s (%s)", name, spec)
+	return nil
+}
+
 // UnscheduleTransaction removes a scheduled transaction
 func (s *Scheduler) UnscheduleTransaction(transactionID string) error {
 	println("⏰ Transaction planı kaldırılıyor, ID:", transactionID)
@@ -124,11 +152,11 @@ func (s *Scheduler) executeScheduledTransaction(st *ScheduledTransaction) {
 
 	// Create transaction event
 	event := events.NewEvent("transaction.scheduled", st.ID, map[string]interface{}{
-		"from_user_id": st.FromUserID,
-		"to_user_id":   st.ToUserID,
-		"amount":       st.Amount,
-		"type":         st.Type,
-		"metadata":     st.Metadata,
+		"from_account_id": st.FromAccountID,
+		"to_account_id":   st.ToAccountID,
+		"amount":          st.Amount,
+		"type":            st.Type,
+		"metadata":        st.Metadata,
 	})
 
 	// Publish event
@@ -0,0 +1,358 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatOFX     Format = "ofx"
+	FormatMT940   Format = "mt940"
+	FormatCAMT053 Format = "camt053"
+)
+
+// ParseFormat accepts the format names used in the query string
+func ParseFormat(v string) (Format, error) {
+	switch strings.ToLower(strings.ReplaceAll(v, ".", "")) {
+	case "", "csv":
+		return FormatCSV, nil
+	case "ofx":
+		return FormatOFX, nil
+	case "mt940":
+		return FormatMT940, nil
+	case "camt053":
+		return FormatCAMT053, nil
+	default:
+		return "", fmt.Errorf("unsupported statement format: %s", v)
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatOFX:
+		return "application/x-ofx"
+	case FormatCAMT053:
+		return "application/xml"
+	case FormatMT940:
+		return "text/plain; charset=utf-8"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+func (f Format) Extension() string {
+	switch f {
+	case FormatOFX:
+		return "ofx"
+	case FormatCAMT053:
+		return "xml"
+	case FormatMT940:
+		return "sta"
+	default:
+		return "csv"
+	}
+}
+
+// Render encodes the statement in format f
+func (s *Statement) Render(f Format) ([]byte, error) {
+	switch f {
+	case FormatCSV:
+		return s.CSV()
+	case FormatOFX:
+		return s.OFX()
+	case FormatMT940:
+		return s.MT940(), nil
+	case FormatCAMT053:
+		return s.CAMT053()
+	default:
+		return nil, fmt.Errorf("unsupported statement format: %s", f)
+	}
+}
+
+// decimal formats cents as "1234.56" using sep as the decimal separator
+func decimal(cents int64, sep string) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d%s%02d", sign, cents/100, sep, cents%100)
+}
+
+func abs(cents int64) int64 {
+	if cents < 0 {
+		return -cents
+	}
+	return cents
+}
+
+// CSV writes one row per line framed by opening and closing balance rows
+func (s *Statement) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	rows := [][]string{
+		{"date", "transaction_id", "type", "description", "amount", "balance", "currency"},
+		{s.From.Format("2006-01-02T15:04:05Z07:00"), "", "opening_balance", "", "", decimal(s.OpeningBalanceCents, "."), s.Currency},
+	}
+	for _, l := range s.Lines {
+		rows = append(rows, []string{
+			l.BookedAt.Format("2006-01-02T15:04:05Z07:00"),
+			strconv.FormatUint(uint64(l.TransactionID), 10),
+			l.Type,
+			l.Description,
+			decimal(l.AmountCents, "."),
+			decimal(l.BalanceCents, "."),
+			s.Currency,
+		})
+	}
+	rows = append(rows, []string{s.To.Format("2006-01-02T15:04:05Z07:00"), "", "closing_balance", "", "", decimal(s.ClosingBalanceCents, "."), s.Currency})
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, fmt.Errorf("failed to write csv: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// MT940 writes a SWIFT customer statement message. The running balance of
+// each line is carried in its :86: information field.
+func (s *Statement) MT940() []byte {
+	var b strings.Builder
+	line := func(format string, args ...interface{}) {
+		b.WriteString(fmt.Sprintf(format, args...))
+		b.WriteString("\r\n")
+	}
+	balance := func(tag string, cents int64, date string) {
+		mark := "C"
+		if cents < 0 {
+			mark = "D"
+		}
+		line(":%s:%s%s%s%s", tag, mark, date, s.Currency, decimal(abs(cents), ","))
+	}
+
+	line(":20:%s", truncate(s.ID(), 16))
+	line(":25:%s", s.Account)
+	line(":28C:1/1")
+	balance("60F", s.OpeningBalanceCents, s.From.Format("060102"))
+	for _, l := range s.Lines {
+		mark := "C"
+		if !l.IsCredit() {
+			mark = "D"
+		}
+		line(":61:%s%s%s%s%s%s//%d", l.BookedAt.Format("060102"), l.BookedAt.Format("0102"), mark,
+			decimal(abs(l.AmountCents), ","), swiftCode(l.Type), "NONREF", l.TransactionID)
+		line(":86:%s BAL %s", truncate(l.Description, 50), decimal(l.BalanceCents, ","))
+	}
+	balance("62F", s.ClosingBalanceCents, s.To.Format("060102"))
+	balance("64", s.ClosingBalanceCents, s.To.Format("060102"))
+	b.WriteString("-")
+	return []byte(b.String())
+}
+
+// swiftCode maps a transaction type to an MT940 transaction type code
+func swiftCode(txType string) string {
+	switch txType {
+	case "transfer", "reversal", "refund":
+		return "NTRF"
+	case "fee":
+		return "NCHG"
+	default:
+		return "NMSC"
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxTransaction struct {
+	Type   string `xml:"TRNTYPE"`
+	Posted string `xml:"DTPOSTED"`
+	Amount string `xml:"TRNAMT"`
+	FITID  string `xml:"FITID"`
+	Name   string `xml:"NAME"`
+	Memo   string `xml:"MEMO"`
+}
+
+type ofxDocument struct {
+	XMLName xml.Name `xml:"OFX"`
+	SignOn  struct {
+		Status   ofxStatus `xml:"STATUS"`
+		Server   string    `xml:"DTSERVER"`
+		Language string    `xml:"LANGUAGE"`
+	} `xml:"SIGNONMSGSRSV1>SONRS"`
+	Statement struct {
+		UID       string           `xml:"TRNUID"`
+		Status    ofxStatus        `xml:"STATUS"`
+		Currency  string           `xml:"STMTRS>CURDEF"`
+		BankID    string           `xml:"STMTRS>BANKACCTFROM>BANKID"`
+		AccountID string           `xml:"STMTRS>BANKACCTFROM>ACCTID"`
+		AcctType  string           `xml:"STMTRS>BANKACCTFROM>ACCTTYPE"`
+		Start     string           `xml:"STMTRS>BANKTRANLIST>DTSTART"`
+		End       string           `xml:"STMTRS>BANKTRANLIST>DTEND"`
+		Entries   []ofxTransaction `xml:"STMTRS>BANKTRANLIST>STMTTRN"`
+		Balance   string           `xml:"STMTRS>LEDGERBAL>BALAMT"`
+		AsOf      string           `xml:"STMTRS>LEDGERBAL>DTASOF"`
+	} `xml:"BANKMSGSRSV1>STMTTRNRS"`
+}
+
+const ofxTime = "20060102150405"
+
+// OFX writes an OFX 2.2 bank statement response. OFX has no running balance
+// element, so it is written into each transaction's MEMO.
+func (s *Statement) OFX() ([]byte, error) {
+	var doc ofxDocument
+	doc.SignOn.Status = ofxStatus{Code: 0, Severity: "INFO"}
+	doc.SignOn.Server = s.GeneratedAt.Format(ofxTime)
+	doc.SignOn.Language = "ENG"
+
+	st := &doc.Statement
+	st.UID = s.ID()
+	st.Status = ofxStatus{Code: 0, Severity: "INFO"}
+	st.Currency = s.Currency
+	st.BankID = "BANKAPI"
+	st.AccountID = s.Account
+	st.AcctType = "CHECKING"
+	st.Start = s.From.Format(ofxTime)
+	st.End = s.To.Format(ofxTime)
+	for _, l := range s.Lines {
+		trnType := "CREDIT"
+		if !l.IsCredit() {
+			trnType = "DEBIT"
+		}
+		if l.Type == "fee" {
+			trnType = "FEE"
+		}
+		st.Entries = append(st.Entries, ofxTransaction{
+			Type:   trnType,
+			Posted: l.BookedAt.Format(ofxTime),
+			Amount: decimal(l.AmountCents, "."),
+			FITID:  strconv.FormatUint(uint64(l.TransactionID), 10),
+			Name:   truncate(l.Type, 32),
+			Memo:   fmt.Sprintf("%s; balance %s", l.Description, decimal(l.BalanceCents, ".")),
+		})
+	}
+	st.Balance = decimal(s.ClosingBalanceCents, ".")
+	st.AsOf = s.To.Format(ofxTime)
+
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write ofx: %w", err)
+	}
+	header := `<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n" +
+		`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+	return append([]byte(header), body...), nil
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtBalance struct {
+	Code   string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount camtAmount `xml:"Amt"`
+	Sign   string     `xml:"CdtDbtInd"`
+	Date   string     `xml:"Dt>Dt"`
+}
+
+type camtEntry struct {
+	Ref        string     `xml:"NtryRef"`
+	Amount     camtAmount `xml:"Amt"`
+	Sign       string     `xml:"CdtDbtInd"`
+	Status     string     `xml:"Sts>Cd"`
+	Booked     string     `xml:"BookgDt>DtTm"`
+	Value      string     `xml:"ValDt>Dt"`
+	ServicerID string     `xml:"AcctSvcrRef"`
+	Code       string     `xml:"BkTxCd>Prtry>Cd"`
+	Details    string     `xml:"NtryDtls>TxDtls>AddtlTxInf"`
+	Info       string     `xml:"AddtlNtryInf"`
+}
+
+type camtDocument struct {
+	XMLName   xml.Name `xml:"urn:iso:std:iso:20022:tech:xsd:camt.053.001.08 Document"`
+	MsgID     string   `xml:"BkToCstmrStmt>GrpHdr>MsgId"`
+	Created   string   `xml:"BkToCstmrStmt>GrpHdr>CreDtTm"`
+	Statement struct {
+		ID       string        `xml:"Id"`
+		Sequence int           `xml:"ElctrncSeqNb"`
+		Created  string        `xml:"CreDtTm"`
+		From     string        `xml:"FrToDt>FrDtTm"`
+		To       string        `xml:"FrToDt>ToDtTm"`
+		Account  string        `xml:"Acct>Id>IBAN"`
+		Currency string        `xml:"Acct>Ccy"`
+		Balances []camtBalance `xml:"Bal"`
+		Entries  []camtEntry   `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+const isoDateTime = "2006-01-02T15:04:05Z07:00"
+
+// CAMT053 writes an ISO 20022 camt.053.001.08 bank-to-customer statement.
+// The running balance of each entry is carried in AddtlNtryInf.
+func (s *Statement) CAMT053() ([]byte, error) {
+	var doc camtDocument
+	doc.MsgID = s.ID()
+	doc.Created = s.GeneratedAt.Format(isoDateTime)
+
+	st := &doc.Statement
+	st.ID = s.ID()
+	st.Sequence = 1
+	st.Created = s.GeneratedAt.Format(isoDateTime)
+	st.From = s.From.Format(isoDateTime)
+	st.To = s.To.Format(isoDateTime)
+	st.Account = s.Account
+	st.Currency = s.Currency
+	st.Balances = []camtBalance{
+		s.camtBalance("OPBD", s.OpeningBalanceCents, s.From.Format("2006-01-02")),
+		s.camtBalance("CLBD", s.ClosingBalanceCents, s.To.Format("2006-01-02")),
+	}
+	for _, l := range s.Lines {
+		sign := "CRDT"
+		if !l.IsCredit() {
+			sign = "DBIT"
+		}
+		ref := strconv.FormatUint(uint64(l.TransactionID), 10)
+		st.Entries = append(st.Entries, camtEntry{
+			Ref:        ref,
+			Amount:     camtAmount{Currency: s.Currency, Value: decimal(abs(l.AmountCents), ".")},
+			Sign:       sign,
+			Status:     "BOOK",
+			Booked:     l.BookedAt.Format(isoDateTime),
+			Value:      l.BookedAt.Format("2006-01-02"),
+			ServicerID: ref,
+			Code:       l.Type,
+			Details:    l.Description,
+			Info:       "Balance after entry: " + decimal(l.BalanceCents, "."),
+		})
+	}
+
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write camt.053: %w", err)
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+func (s *Statement) camtBalance(code string, cents int64, date string) camtBalance {
+	sign := "CRDT"
+	if cents < 0 {
+		sign = "DBIT"
+	}
+	return camtBalance{Code: code, Amount: camtAmount{Currency: s.Currency, Value: decimal(abs(cents), ".")}, Sign: sign, Date: date}
+}
@@ -0,0 +1,116 @@
+package statement
+
+import (
+	"bankapi/internal/account"
+	"bankapi/internal/currency"
+	"bankapi/internal/middleware"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct{}
+
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// GetStatement exports a statement for ?account_id (default the caller's
+// first account), ?currency and the
+// ?from/?to period in ?format=csv|ofx|mt940|camt053. Dates may be RFC3339 or
+// YYYY-MM-DD; a plain to date covers that whole day. The period defaults to
+// the current month up to now.
+func (h *Handler) GetStatement(c *gin.Context) {
+	format, err := ParseFormat(c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format csv, ofx, mt940 veya camt053 olmalı"})
+		return
+	}
+
+	u, authenticated := middleware.CurrentUser(c)
+	var a *account.Account
+	if v := c.Query("account_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || id == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "account_id geçersiz"})
+			return
+		}
+		if a, err = account.Get(uint(id)); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "hesap bulunamadı"})
+			return
+		}
+	} else if authenticated {
+		accounts, err := account.List(u.ID)
+		if err != nil || len(accounts) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "hesap bulunamadı"})
+			return
+		}
+		a = &accounts[0]
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account_id gerekli"})
+		return
+	}
+
+	// Non-admins only ever get statements of their own accounts
+	if authenticated && !u.IsAdmin() && a.UserID != u.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Erişim reddedildi"})
+		return
+	}
+
+	code := strings.ToUpper(c.DefaultQuery("currency", currency.DefaultCurrency))
+	if !currency.Default().IsSupported(code) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "desteklenmeyen para birimi"})
+		return
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to := now
+	if v := c.Query("from"); v != "" {
+		if from, err = parseDate(v, false); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from tarih formatı RFC3339 veya YYYY-MM-DD olmalı"})
+			return
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = parseDate(v, true); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to tarih formatı RFC3339 veya YYYY-MM-DD olmalı"})
+			return
+		}
+	}
+
+	s, err := Build(a, code, from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	body, err := s.Render(format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ekstre oluşturulamadı"})
+		return
+	}
+
+	filename := fmt.Sprintf("statement-%d-%s-%s-%s.%s", a.ID, code, from.Format("20060102"), to.Format("20060102"), format.Extension())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, format.ContentType(), body)
+}
+
+// parseDate accepts RFC3339 or a plain date. A plain end date is moved to the
+// last instant of that day so the whole day is included.
+func parseDate(v string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
@@ -0,0 +1,18 @@
+package statement
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
+	s := router.Group("/api/v1/statements")
+
+	// Only use auth middleware if it's provided
+	if authMiddleware != nil {
+		s.Use(authMiddleware)
+	}
+
+	handler := NewHandler()
+
+	s.GET("", handler.GetStatement)
+}
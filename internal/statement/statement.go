@@ -0,0 +1,156 @@
+package statement
+
+import (
+	"bankapi/internal/account"
+	"bankapi/internal/balance"
+	"bankapi/internal/db"
+	"bankapi/internal/transaction"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Line is one booked movement with the balance after it
+type Line struct {
+	TransactionID uint      `json:"transaction_id"`
+	BookedAt      time.Time `json:"booked_at"`
+	Type          string    `json:"type"`
+	Description   string    `json:"description"`
+	AmountCents   int64     `json:"amount_cents"` // negative for debits
+	BalanceCents  int64     `json:"balance_cents"`
+}
+
+// IsCredit reports whether the line increases the balance
+func (l Line) IsCredit() bool {
+	return l.AmountCents >= 0
+}
+
+// Statement is an account's movements in one currency over [From, To]
+type Statement struct {
+	AccountID           uint      `json:"account_id"`
+	Account             string    `json:"account"` // IBAN
+	Currency            string    `json:"currency"`
+	From                time.Time `json:"from"`
+	To                  time.Time `json:"to"`
+	OpeningBalanceCents int64     `json:"opening_balance_cents"`
+	ClosingBalanceCents int64     `json:"closing_balance_cents"`
+	Lines               []Line    `json:"lines"`
+	GeneratedAt         time.Time `json:"generated_at"`
+}
+
+// ID identifies the statement in the exported formats
+func (s *Statement) ID() string {
+	return fmt.Sprintf("STMT%d%s%s", s.AccountID, s.Currency, s.To.Format("20060102"))
+}
+
+// bookedStatuses are the statuses of transactions that moved money. A
+// reversed or refunded original still booked its amount; the compensation
+// is a line of its own.
+var bookedStatuses = []transaction.TransactionStatus{
+	transaction.TransactionStatusCompleted,
+	transaction.TransactionStatusReversed,
+	transaction.TransactionStatusPartiallyRefunded,
+	transaction.TransactionStatusRefunded,
+}
+
+// Build assembles the statement. The opening balance is the last balance
+// history entry before from; every booked transaction in the period is then
+// applied to produce the running and closing balances.
+func Build(a *account.Account, currency string, from, to time.Time) (*Statement, error) {
+	println("🧾 Ekstre hazırlanıyor, hesap ID:", a.ID, "para birimi:", currency)
+
+	if !to.After(from) {
+		return nil, fmt.Errorf("to must be after from")
+	}
+
+	opening, err := balanceBefore(a.ID, currency, from)
+	if err != nil {
+		return nil, err
+	}
+
+	var txs []transaction.Transaction
+	if err := db.DB.
+		Where("status IN ?", bookedStatuses).
+		Where("created_at >= ? AND created_at <= ?", from, to).
+		Where("(from_account_id = ? AND currency = ?) OR (to_account_id = ? AND COALESCE(NULLIF(to_currency, ''), currency) = ?)", a.ID, currency, a.ID, currency).
+		Order("created_at ASC, id ASC").
+		Find(&txs).Error; err != nil {
+		return nil, fmt.Errorf("failed to load transactions: %w", err)
+	}
+
+	s := &Statement{
+		AccountID:           a.ID,
+		Account:             a.IBAN,
+		Currency:            currency,
+		From:                from,
+		To:                  to,
+		OpeningBalanceCents: opening,
+		Lines:               make([]Line, 0, len(txs)),
+		GeneratedAt:         time.Now(),
+	}
+
+	running := opening
+	for i := range txs {
+		for _, amount := range signedAmounts(&txs[i], a.ID, currency) {
+			running += amount
+			s.Lines = append(s.Lines, Line{
+				TransactionID: txs[i].ID,
+				BookedAt:      txs[i].CreatedAt,
+				Type:          string(txs[i].Type),
+				Description:   describe(&txs[i], a.ID),
+				AmountCents:   amount,
+				BalanceCents:  running,
+			})
+		}
+	}
+	s.ClosingBalanceCents = running
+
+	println("✅ Ekstre hazırlandı, satır sayısı:", len(s.Lines))
+	return s, nil
+}
+
+// balanceBefore returns the balance recorded last before t, or zero
+func balanceBefore(accountID uint, currency string, t time.Time) (int64, error) {
+	var h balance.BalanceHistory
+	err := db.DB.Where("account_id = ? AND currency = ? AND created_at < ?", accountID, currency, t).
+		Order("created_at DESC, id DESC").
+		First(&h).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to load opening balance: %w", err)
+	}
+	return h.AmountCents, nil
+}
+
+// signedAmounts returns the movements of tx on the account's balance in
+// currency. An FX transfer between an account's own balances yields only the
+// leg in the requested currency.
+func signedAmounts(tx *transaction.Transaction, accountID uint, currency string) []int64 {
+	var amounts []int64
+	if tx.FromAccountID != nil && *tx.FromAccountID == accountID && tx.Currency == currency {
+		amounts = append(amounts, -tx.AmountCents)
+	}
+	if tx.ToAccountID != nil && *tx.ToAccountID == accountID && tx.CreditCurrency() == currency {
+		amounts = append(amounts, tx.CreditAmountCents())
+	}
+	return amounts
+}
+
+func describe(tx *transaction.Transaction, accountID uint) string {
+	switch {
+	case tx.ParentTransactionID != nil:
+		return fmt.Sprintf("%s for transaction %d", tx.Type, *tx.ParentTransactionID)
+	case tx.OriginalTransactionID != nil:
+		return fmt.Sprintf("%s of transaction %d", tx.Type, *tx.OriginalTransactionID)
+	case tx.FromAccountID != nil && *tx.FromAccountID == accountID && tx.ToAccountID != nil && *tx.ToAccountID != accountID:
+		return fmt.Sprintf("%s to account %d", tx.Type, *tx.ToAccountID)
+	case tx.ToAccountID != nil && *tx.ToAccountID == accountID && tx.FromAccountID != nil && *tx.FromAccountID != accountID:
+		return fmt.Sprintf("%s from account %d", tx.Type, *tx.FromAccountID)
+	default:
+		return string(tx.Type)
+	}
+}
@@ -0,0 +1,35 @@
+package transaction
+
+import (
+	"bankapi/internal/account"
+	"fmt"
+)
+
+// bindAccounts loads the paying and receiving accounts, records them and
+// their owners on txModel and, when enforce is set, rejects accounts that may
+// not pay out or receive. A nil side is the bank itself.
+func bindAccounts(txModel *Transaction, fromID, toID *uint, enforce bool) error {
+	if fromID != nil {
+		a, err := account.Get(*fromID)
+		if err != nil {
+			return err
+		}
+		if enforce && !a.CanDebit() {
+			return fmt.Errorf("%w: account %d is %s", account.ErrAccountInactive, a.ID, a.Status)
+		}
+		txModel.FromAccountID = &a.ID
+		txModel.FromUserID = &a.UserID
+	}
+	if toID != nil {
+		a, err := account.Get(*toID)
+		if err != nil {
+			return err
+		}
+		if enforce && !a.CanCredit() {
+			return fmt.Errorf("%w: account %d is %s", account.ErrAccountInactive, a.ID, a.Status)
+		}
+		txModel.ToAccountID = &a.ID
+		txModel.ToUserID = &a.UserID
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package transaction
+
+import (
+	"bankapi/internal/approval"
+	"bankapi/internal/currency"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// NeedsApproval reports whether a transfer is above the maker-checker
+// threshold, which is expressed in the default currency.
+func NeedsApproval(cur string, amount int64) (bool, error) {
+	converted := float64(amount)
+	if cur != currency.DefaultCurrency {
+		var err error
+		converted, err = currency.Default().Convert(converted, cur, currency.DefaultCurrency)
+		if err != nil {
+			return false, fmt.Errorf("failed to convert %s: %w", cur, err)
+		}
+	}
+	return int64(math.Round(converted)) > approval.TransferThreshold(), nil
+}
+
+// executeApprovedTransfer is the approval executor for parked transfers. The
+// transfer goes through the usual screening, limits and fees when it runs;
+// a value-dated one is scheduled unless its date has passed meanwhile.
+func executeApprovedTransfer(payload []byte) (interface{}, error) {
+	var req CreateTransferRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return nil, err
+	}
+	if req.ExecuteAt != nil && req.ExecuteAt.After(time.Now()) {
+		return ScheduleTransfer(req.FromAccountID, req.ToAccountID, req.Currency, req.ToCurrency, req.AmountCents, *req.ExecuteAt)
+	}
+	return ApplyTransfer(req.FromAccountID, req.ToAccountID, req.Currency, req.ToCurrency, req.AmountCents)
+}
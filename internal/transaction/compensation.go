@@ -0,0 +1,128 @@
+package transaction
+
+import (
+	"bankapi/internal/audit"
+	"bankapi/internal/db"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrNotCompensatable    = errors.New("transaction cannot be reversed or refunded")
+)
+
+// Reverse fully undoes a completed transaction with a linked reversal,
+// including the fee charged for it
+func Reverse(originalID uint, reason string) (*Transaction, *Transaction, error) {
+	println("↩️ İşlem geri alınıyor, ID:", originalID)
+	return compensate(originalID, TransactionTypeReversal, 0, reason)
+}
+
+// Refund returns part or all of a completed transaction with a linked refund.
+// Unlike a reversal it keeps the fee: the payment was made as charged and the
+// payee is choosing to give money back.
+func Refund(originalID uint, amount int64, reason string) (*Transaction, *Transaction, error) {
+	println("💱 İade yapılıyor, ID:", originalID, "miktar:", amount, "kuruş")
+	if amount <= 0 {
+		return nil, nil, fmt.Errorf("refund amount must be positive")
+	}
+	return compensate(originalID, TransactionTypeRefund, amount, reason)
+}
+
+// compensate creates the linked compensating transaction, moves the money back
+// (and the fee, for a reversal) and updates the original's status, all in one
+// database transaction. The
+// original row is locked so concurrent refunds cannot exceed its amount.
+func compensate(originalID uint, kind TransactionType, amount int64, reason string) (*Transaction, *Transaction, error) {
+	var original Transaction
+	if err := db.DB.First(&original, originalID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrTransactionNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to load transaction: %w", err)
+	}
+	if kind == TransactionTypeReversal {
+		amount = original.AmountCents
+	}
+	if err := checkCompensatable(&original, kind, amount); err != nil {
+		return &original, nil, err
+	}
+
+	// Money flows the opposite way of the original
+	comp := &Transaction{
+		FromAccountID:         original.ToAccountID,
+		ToAccountID:           original.FromAccountID,
+		FromUserID:            original.ToUserID,
+		ToUserID:              original.FromUserID,
+		AmountCents:           amount,
+		Currency:              original.Currency,
+		Type:                  kind,
+		Status:                TransactionStatusPending,
+		OriginalTransactionID: &original.ID,
+	}
+	if original.IsFX() {
+		// The receiver gives back its share of the converted leg at the
+		// original rate and the sender gets the refunded source amount
+		comp.Currency = original.ToCurrency
+		comp.AmountCents = original.ToAmountCents * amount / original.AmountCents
+		comp.ToCurrency = original.Currency
+		comp.ToAmountCents = amount
+		comp.FXRate = original.FXRate
+		comp.FXSpreadBps = original.FXSpreadBps
+		comp.FXAppliedRate = original.FXAppliedRate
+		if comp.AmountCents <= 0 {
+			return &original, nil, fmt.Errorf("%w: refund amount too small to convert", ErrNotCompensatable)
+		}
+	}
+
+	err := execute(comp, func(dbTx *gorm.DB) error {
+		if err := dbTx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).First(&original, originalID).Error; err != nil {
+			return fmt.Errorf("failed to lock transaction: %w", err)
+		}
+		if err := checkCompensatable(&original, kind, amount); err != nil {
+			return err
+		}
+
+		if err := move(dbTx, comp, comp.FromAccountID, comp.ToAccountID); err != nil {
+			return err
+		}
+
+		if kind == TransactionTypeReversal {
+			if err := original.Rollback(); err != nil {
+				return err
+			}
+			if err := reverseFee(dbTx, &original, comp); err != nil {
+				return err
+			}
+		} else if err := original.Refund(amount); err != nil {
+			return err
+		}
+		if err := dbTx.Save(&original).Error; err != nil {
+			return fmt.Errorf("failed to update original transaction: %w", err)
+		}
+
+		return audit.LogTx(dbTx, "transaction", fmt.Sprintf("%d", original.ID), string(kind),
+			fmt.Sprintf("by=%d amount=%d status=%s reason=%s", comp.ID, amount, original.Status, reason))
+	})
+	if err != nil {
+		println("❌ Telafi işlemi başarısız:", err.Error())
+		return &original, comp, err
+	}
+
+	println("✅ Telafi işlemi tamamlandı, ID:", comp.ID, "orijinal durum:", string(original.Status))
+	return &original, comp, nil
+}
+
+func checkCompensatable(original *Transaction, kind TransactionType, amount int64) error {
+	if kind == TransactionTypeReversal && !original.CanRollback() {
+		return fmt.Errorf("%w: status=%s type=%s", ErrNotCompensatable, original.Status, original.Type)
+	}
+	if kind == TransactionTypeRefund && !original.CanRefund(amount) {
+		return fmt.Errorf("%w: status=%s refundable=%d", ErrNotCompensatable, original.Status, original.RefundableCents())
+	}
+	return nil
+}
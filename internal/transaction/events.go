@@ -0,0 +1,58 @@
+package transaction
+
+import (
+	"bankapi/internal/db"
+	"bankapi/internal/events"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Events queued in the outbox when a transaction settles or fails
+const (
+	EventCompleted = "transaction.completed"
+	EventFailed    = "transaction.failed"
+)
+
+// emitTx queues an event describing txModel inside dbTx, so it is published
+// only if dbTx commits
+func emitTx(dbTx *gorm.DB, txModel *Transaction, eventType string) error {
+	data := map[string]interface{}{
+		"transaction_id":  txModel.ID,
+		"type":            txModel.Type,
+		"status":          txModel.Status,
+		"from_account_id": txModel.FromAccountID,
+		"to_account_id":   txModel.ToAccountID,
+		"amount_cents":    txModel.AmountCents,
+		"currency":        txModel.Currency,
+		"fee_cents":       txModel.FeeCents,
+	}
+	if txModel.IsFX() {
+		data["to_currency"] = txModel.ToCurrency
+		data["to_amount_cents"] = txModel.ToAmountCents
+	}
+	if txModel.FailureCause != "" {
+		data["failure_cause"] = txModel.FailureCause
+	}
+	return events.EnqueueTx(dbTx, events.NewEvent(eventType, fmt.Sprintf("%d", txModel.ID), data))
+}
+
+// markFailed moves txModel from status from to failed with cause and queues
+// its transaction.failed event. A row no longer in status from, e.g. because
+// it was cancelled or settled meanwhile, is left alone.
+func markFailed(txModel *Transaction, from TransactionStatus, cause error) error {
+	return db.DB.Transaction(func(dbTx *gorm.DB) error {
+		res := dbTx.Model(&Transaction{}).Where("id = ? AND status = ?", txModel.ID, from).Updates(map[string]interface{}{
+			"status":        TransactionStatusFailed,
+			"failure_cause": cause.Error(),
+		})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		txModel.Status = TransactionStatusFailed
+		txModel.FailureCause = cause.Error()
+		txModel.FeeCents = 0
+		txModel.FeeTransaction = nil
+		return emitTx(dbTx, txModel, EventFailed)
+	})
+}
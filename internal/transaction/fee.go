@@ -0,0 +1,113 @@
+package transaction
+
+import (
+	"bankapi/internal/audit"
+	"bankapi/internal/balance"
+	"bankapi/internal/fee"
+	"bankapi/internal/ledger"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// chargeFee prices txModel against the fee rules and, when a fee applies,
+// debits it from txModel's paying account as a separate fee transaction
+// linked to txModel and posted to the bank's fee income account. It runs
+// inside the same dbTx so the payer must be able to cover the amount and the
+// fee together.
+func chargeFee(dbTx *gorm.DB, txModel *Transaction, role string) error {
+	amount, rule, err := fee.Calculate(dbTx, string(txModel.Type), role, txModel.Currency, txModel.AmountCents)
+	if err != nil {
+		return err
+	}
+	if rule == nil || amount <= 0 {
+		return nil
+	}
+
+	accountID := *txModel.FromAccountID
+	feeTx := &Transaction{
+		FromAccountID:       txModel.FromAccountID,
+		FromUserID:          txModel.FromUserID,
+		AmountCents:         amount,
+		Currency:            txModel.Currency,
+		Type:                TransactionTypeFee,
+		Status:              TransactionStatusCompleted,
+		ParentTransactionID: &txModel.ID,
+	}
+	if err := dbTx.Create(feeTx).Error; err != nil {
+		println("❌ Ücret işlemi oluşturulamadı:", err.Error())
+		return fmt.Errorf("failed to create fee transaction: %w", err)
+	}
+
+	if err := balance.DebitTx(dbTx, accountID, txModel.Currency, amount, feeTx.entry()); err != nil {
+		println("❌ Ücret tahsil edilemedi:", err.Error())
+		return err
+	}
+	if _, err := ledger.PostTx(dbTx, &feeTx.ID, fmt.Sprintf("fee: %s", rule.Name),
+		ledger.Debit(ledger.CustomerAccount(accountID), txModel.Currency, amount),
+		ledger.Credit(ledger.AccountFeeIncome, txModel.Currency, amount),
+	); err != nil {
+		return err
+	}
+
+	txModel.FeeCents = amount
+	txModel.FeeTransaction = feeTx
+	println("🧾 Ücret tahsil edildi:", amount, txModel.Currency, "transaction ID:", feeTx.ID)
+	return audit.LogTx(dbTx, "transaction", fmt.Sprintf("%d", feeTx.ID), "fee", fmt.Sprintf("parent=%d rule=%d amount=%d %s", txModel.ID, rule.ID, amount, txModel.Currency))
+}
+
+// reverseFee gives back the fee charged for original when it is reversed.
+// The fee is returned with its own reversal transaction linked to the fee
+// and posted back out of fee income. It runs inside the
+// reversal's dbTx after move has locked the payer's balance. A transaction
+// without a completed fee is left alone.
+func reverseFee(dbTx *gorm.DB, original, reversal *Transaction) error {
+	var feeTx Transaction
+	err := dbTx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Where("parent_transaction_id = ? AND type = ? AND status = ?", original.ID, TransactionTypeFee, TransactionStatusCompleted).
+		First(&feeTx).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load fee transaction: %w", err)
+	}
+
+	accountID := *feeTx.FromAccountID
+	back := &Transaction{
+		ToAccountID:           feeTx.FromAccountID,
+		ToUserID:              feeTx.FromUserID,
+		AmountCents:           feeTx.AmountCents,
+		Currency:              feeTx.Currency,
+		Type:                  TransactionTypeReversal,
+		Status:                TransactionStatusCompleted,
+		OriginalTransactionID: &feeTx.ID,
+	}
+	if err := dbTx.Create(back).Error; err != nil {
+		println("❌ Ücret iadesi oluşturulamadı:", err.Error())
+		return fmt.Errorf("failed to create fee reversal: %w", err)
+	}
+
+	if err := balance.CreditTx(dbTx, accountID, feeTx.Currency, feeTx.AmountCents, back.entry()); err != nil {
+		return err
+	}
+	if _, err := ledger.PostTx(dbTx, &back.ID, fmt.Sprintf("fee reversal: %d", feeTx.ID),
+		ledger.Debit(ledger.AccountFeeIncome, feeTx.Currency, feeTx.AmountCents),
+		ledger.Credit(ledger.CustomerAccount(accountID), feeTx.Currency, feeTx.AmountCents),
+	); err != nil {
+		return err
+	}
+
+	if err := feeTx.TransitionTo(TransactionStatusReversed); err != nil {
+		return err
+	}
+	if err := dbTx.Save(&feeTx).Error; err != nil {
+		return fmt.Errorf("failed to update fee transaction: %w", err)
+	}
+
+	reversal.FeeTransaction = back
+	println("🧾 Ücret iade edildi:", feeTx.AmountCents, feeTx.Currency, "transaction ID:", back.ID)
+	return audit.LogTx(dbTx, "transaction", fmt.Sprintf("%d", feeTx.ID), string(TransactionTypeReversal), fmt.Sprintf("by=%d parent=%d amount=%d %s", back.ID, original.ID, feeTx.AmountCents, feeTx.Currency))
+}
@@ -1,19 +1,48 @@
 package transaction
 
 import (
+	"bankapi/internal/account"
+	"bankapi/internal/approval"
+	"bankapi/internal/balance"
+	"bankapi/internal/currency"
 	"bankapi/internal/db"
+	"bankapi/internal/fraud"
+	"bankapi/internal/idempotency"
+	"bankapi/internal/limits"
+	"bankapi/internal/middleware"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func RegisterRoutes(router *gin.Engine, middlewares ...gin.HandlerFunc) {
+	approval.RegisterExecutor(approval.ActionTransfer, executeApprovedTransfer)
+
 	r := router.Group("/api/v1/transactions", middlewares...)
 	{
-		r.POST("/credit", handleCredit)
-		r.POST("/debit", handleDebit)
-		r.POST("/transfer", handleTransfer)
+		r.POST("/credit", idempotency.Middleware(), handleCredit)
+		r.POST("/debit", idempotency.Middleware(), handleDebit)
+		r.POST("/transfer", idempotency.Middleware(), handleTransfer)
 		r.GET("/history", handleHistory)
 		r.GET("/:id", handleGetByID)
+		r.POST("/:id/reverse", middleware.RequireRoles("admin"), idempotency.Middleware(), handleReverse)
+		r.POST("/:id/refund", idempotency.Middleware(), handleRefund)
+		r.POST("/:id/cancel", handleCancel)
+		r.POST("/:id/approve", middleware.RequireRoles("admin"), handleApproveReview)
+		r.POST("/:id/reject", middleware.RequireRoles("admin"), handleRejectReview)
+	}
+
+	h := router.Group("/api/v1/holds", middlewares...)
+	{
+		h.POST("", idempotency.Middleware(), handlePlaceHold)
+		h.GET("", handleListHolds)
+		h.GET("/:id", handleGetHold)
+		h.POST("/:id/capture", idempotency.Middleware(), handleCaptureHold)
+		h.POST("/:id/void", handleVoidHold)
 	}
 }
 
@@ -23,7 +52,7 @@ func handleCredit(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Geçersiz veri"})
 		return
 	}
-	tx, err := ApplyCredit(req.UserID, req.AmountCents)
+	tx, err := ApplyCredit(req.AccountID, currencyOrDefault(req.Currency), req.AmountCents)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "transaction": tx})
 		return
@@ -37,9 +66,12 @@ func handleDebit(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Geçersiz veri"})
 		return
 	}
-	tx, err := ApplyDebit(req.UserID, req.AmountCents)
+	if _, ok := authorizeAccount(c, req.AccountID); !ok {
+		return
+	}
+	tx, err := ApplyDebit(req.AccountID, currencyOrDefault(req.Currency), req.AmountCents)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "transaction": tx})
+		respondApplyError(c, tx, err)
 		return
 	}
 	c.JSON(http.StatusCreated, tx)
@@ -51,22 +83,109 @@ func handleTransfer(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Geçersiz veri"})
 		return
 	}
-	tx, err := ApplyTransfer(req.FromUserID, req.ToUserID, req.AmountCents)
+	// Only the owner of the paying account, or an admin, can send from it
+	if _, ok := authorizeAccount(c, req.FromAccountID); !ok {
+		return
+	}
+	fromCur := currencyOrDefault(req.Currency)
+	toCur := fromCur
+	if req.ToCurrency != "" {
+		toCur = req.ToCurrency
+	}
+	if req.ToIBAN != "" {
+		to, err := resolveIBAN(req.ToIBAN)
+		if err != nil {
+			respondAccountError(c, err)
+			return
+		}
+		if req.ToAccountID != 0 && req.ToAccountID != to.ID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to_account_id ve to_iban farklı hesapları gösteriyor"})
+			return
+		}
+		req.ToAccountID = to.ID
+	}
+
+	large, err := NeedsApproval(fromCur, req.AmountCents)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "transaction": tx})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if large {
+		var requesterID uint
+		if u, ok := middleware.CurrentUser(c); ok {
+			requesterID = u.ID
+		}
+		req.Currency, req.ToCurrency = fromCur, toCur
+		pending, err := approval.Submit(approval.ActionTransfer, "", req, requesterID,
+			fmt.Sprintf("transfer %d %s from account %d to account %d", req.AmountCents, fromCur, req.FromAccountID, req.ToAccountID))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "onay talebi oluşturulamadı"})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"message": "transfer ikinci bir admin onayı bekliyor", "approval": pending})
+		return
+	}
+
+	if req.ExecuteAt != nil {
+		tx, err := ScheduleTransfer(req.FromAccountID, req.ToAccountID, fromCur, toCur, req.AmountCents, *req.ExecuteAt)
+		if err != nil {
+			respondApplyError(c, tx, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, tx)
+		return
+	}
+
+	tx, err := ApplyTransfer(req.FromAccountID, req.ToAccountID, fromCur, toCur, req.AmountCents)
+	if err != nil {
+		respondApplyError(c, tx, err)
+		return
+	}
+	if tx.Status == TransactionStatusPendingReview {
+		c.JSON(http.StatusAccepted, tx)
 		return
 	}
 	c.JSON(http.StatusCreated, tx)
 }
 
 func handleHistory(c *gin.Context) {
-	// basic: list latest transactions; can add filters later
-	var txs []Transaction
-	if err := db.DB.Order("id DESC").Limit(100).Find(&txs).Error; err != nil {
+	filter, err := ParseHistoryFilter(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Non-admins only ever see transactions they sent or received
+	if u, ok := middleware.CurrentUser(c); ok && !u.IsAdmin() {
+		if filter.UserID != nil && *filter.UserID != u.ID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Erişim reddedildi"})
+			return
+		}
+		filter.UserID = &u.ID
+		if filter.AccountID != nil {
+			a, err := account.Get(*filter.AccountID)
+			if err != nil || a.UserID != u.ID {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Erişim reddedildi"})
+				return
+			}
+		}
+	}
+
+	txs, next, err := QueryHistory(filter)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "geçmiş getirilemedi"})
 		return
 	}
-	c.JSON(http.StatusOK, txs)
+
+	resp := gin.H{"transactions": txs, "next_cursor": nil, "next": nil}
+	if next != nil {
+		cursor := next.Encode()
+		q := c.Request.URL.Query()
+		q.Set("cursor", cursor)
+		resp["next_cursor"] = cursor
+		resp["next"] = c.Request.URL.Path + "?" + q.Encode()
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 func handleGetByID(c *gin.Context) {
@@ -76,5 +195,341 @@ func handleGetByID(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "işlem bulunamadı"})
 		return
 	}
+	if u, ok := middleware.CurrentUser(c); ok && !u.IsAdmin() && !tx.Involves(u.ID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "işlem bulunamadı"})
+		return
+	}
 	c.JSON(http.StatusOK, tx)
 }
+
+func handleReverse(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "işlem ID geçersiz"})
+		return
+	}
+	var req ReverseRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Geçersiz veri"})
+			return
+		}
+	}
+	original, tx, err := Reverse(uint(id), req.Reason)
+	if err != nil {
+		respondCompensationError(c, err, original, tx)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"original": original, "transaction": tx})
+}
+
+// handleRefund returns money to the payer. Only the party that was credited,
+// or an admin, can give it back.
+func handleRefund(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "işlem ID geçersiz"})
+		return
+	}
+	var req RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Geçersiz veri"})
+		return
+	}
+	if u, ok := middleware.CurrentUser(c); ok && !u.IsAdmin() {
+		var original Transaction
+		if err := db.DB.First(&original, id).Error; err != nil || !original.Involves(u.ID) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "işlem bulunamadı"})
+			return
+		}
+		if original.ToUserID == nil || *original.ToUserID != u.ID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "yalnızca alıcı iade yapabilir"})
+			return
+		}
+	}
+	original, tx, err := Refund(uint(id), req.AmountCents, req.Reason)
+	if err != nil {
+		respondCompensationError(c, err, original, tx)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"original": original, "transaction": tx})
+}
+
+// handleCancel cancels a scheduled transfer before it runs. Users can only
+// cancel transfers they pay.
+func handleCancel(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "işlem ID geçersiz"})
+		return
+	}
+	var userID *uint
+	if u, ok := middleware.CurrentUser(c); ok && !u.IsAdmin() {
+		userID = &u.ID
+	}
+	tx, err := CancelScheduled(uint(id), userID)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, tx)
+	case errors.Is(err, ErrTransactionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "işlem bulunamadı"})
+	case errors.Is(err, ErrNotCancellable):
+		c.JSON(http.StatusConflict, gin.H{"error": "yalnızca henüz çalışmamış planlı transferler iptal edilebilir", "transaction": tx})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "işlem iptal edilemedi"})
+	}
+}
+
+func respondCompensationError(c *gin.Context, err error, original, tx *Transaction) {
+	switch {
+	case errors.Is(err, ErrTransactionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "işlem bulunamadı"})
+	case errors.Is(err, ErrNotCompensatable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "original": original})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "original": original, "transaction": tx})
+	}
+}
+
+// respondApplyError maps a failed debit or transfer to a response. Limit
+// breaches are 422 so clients can tell them apart from bad input.
+func respondApplyError(c *gin.Context, tx *Transaction, err error) {
+	var exceeded *limits.ExceededError
+	if errors.As(err, &exceeded) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":            "işlem limiti aşıldı",
+			"transaction_type": exceeded.TransactionType,
+			"period":           exceeded.Period,
+			"limit_cents":      exceeded.LimitCents,
+			"used_cents":       exceeded.UsedCents,
+			"remaining_cents":  exceeded.RemainingCents(),
+			"currency":         limits.Currency,
+			"transaction":      tx,
+		})
+		return
+	}
+	if errors.Is(err, fraud.ErrBlocked) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "işlem güvenlik kontrolü tarafından engellendi", "transaction": tx})
+		return
+	}
+	if errors.Is(err, account.ErrAccountNotFound) || errors.Is(err, account.ErrAccountInactive) {
+		respondAccountError(c, err)
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "transaction": tx})
+}
+
+func handleApproveReview(c *gin.Context) {
+	handleReview(c, ApproveReview)
+}
+
+func handleRejectReview(c *gin.Context) {
+	handleReview(c, RejectReview)
+}
+
+func handleReview(c *gin.Context, resolve func(id uint, reviewerID *uint, note string) (*Transaction, error)) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "işlem ID geçersiz"})
+		return
+	}
+	var req ReviewDecisionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Geçersiz veri"})
+			return
+		}
+	}
+	var reviewerID *uint
+	if u, ok := middleware.CurrentUser(c); ok {
+		reviewerID = &u.ID
+	}
+
+	tx, err := resolve(uint(id), reviewerID, req.Note)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, tx)
+	case errors.Is(err, ErrTransactionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "işlem bulunamadı"})
+	case errors.Is(err, fraud.ErrNotUnderReview):
+		c.JSON(http.StatusConflict, gin.H{"error": "işlem incelemede değil", "transaction": tx})
+	default:
+		respondApplyError(c, tx, err)
+	}
+}
+
+// resolveIBAN finds the internal account an IBAN belongs to. Transfers to
+// other banks are not supported.
+func resolveIBAN(iban string) (*account.Account, error) {
+	info, err := account.ParseIBAN(iban)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Internal {
+		return nil, fmt.Errorf("transfers to other banks are not supported: %s", info.BankCode)
+	}
+	return account.GetByIBAN(info.IBAN)
+}
+
+// respondAccountError maps an unknown or unusable account to a response
+func respondAccountError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, account.ErrAccountNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "hesap bulunamadı"})
+	case errors.Is(err, account.ErrAccountInactive):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// Lookups the handlers authorize with; tests replace them
+var (
+	getAccount = account.Get
+	getHold    = balance.GetHold
+)
+
+// authorizeAccount loads an account the caller wants to act on and answers
+// the request when it does not exist or belongs to another user. Admins can
+// act on any account.
+func authorizeAccount(c *gin.Context, accountID uint) (*account.Account, bool) {
+	a, err := getAccount(accountID)
+	if err != nil {
+		respondAccountError(c, err)
+		return nil, false
+	}
+	if u, ok := middleware.CurrentUser(c); ok && !u.IsAdmin() && a.UserID != u.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Erişim reddedildi"})
+		return nil, false
+	}
+	return a, true
+}
+
+func currencyOrDefault(code string) string {
+	if code == "" {
+		return currency.DefaultCurrency
+	}
+	return strings.ToUpper(code)
+}
+
+func handlePlaceHold(c *gin.Context) {
+	var req PlaceHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Geçersiz veri"})
+		return
+	}
+	code := currencyOrDefault(req.Currency)
+	if err := checkCurrency(code); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	a, ok := authorizeAccount(c, req.AccountID)
+	if !ok {
+		return
+	}
+	if !a.CanDebit() {
+		respondAccountError(c, fmt.Errorf("%w: account %d is %s", account.ErrAccountInactive, a.ID, a.Status))
+		return
+	}
+	hold, err := balance.PlaceHold(req.AccountID, code, req.AmountCents, req.Reference, time.Duration(req.ExpiresInSeconds)*time.Second)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, hold)
+}
+
+func handleListHolds(c *gin.Context) {
+	accountID, err := strconv.ParseUint(c.Query("account_id"), 10, 64)
+	if err != nil || accountID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account_id geçersiz"})
+		return
+	}
+	if _, ok := authorizeAccount(c, uint(accountID)); !ok {
+		return
+	}
+	holds, err := balance.ListHolds(uint(accountID), balance.HoldStatus(c.Query("status")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "provizyonlar getirilemedi"})
+		return
+	}
+	c.JSON(http.StatusOK, holds)
+}
+
+func handleGetHold(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provizyon ID geçersiz"})
+		return
+	}
+	hold, ok := authorizeHold(c, uint(id))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, hold)
+}
+
+func handleCaptureHold(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provizyon ID geçersiz"})
+		return
+	}
+	var req CaptureHoldRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Geçersiz veri"})
+			return
+		}
+	}
+	if _, ok := authorizeHold(c, uint(id)); !ok {
+		return
+	}
+	tx, err := CaptureHold(uint(id), req.AmountCents)
+	if err != nil {
+		respondHoldError(c, err, tx)
+		return
+	}
+	c.JSON(http.StatusCreated, tx)
+}
+
+func handleVoidHold(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provizyon ID geçersiz"})
+		return
+	}
+	if _, ok := authorizeHold(c, uint(id)); !ok {
+		return
+	}
+	hold, err := balance.VoidHold(uint(id))
+	if err != nil {
+		respondHoldError(c, err, nil)
+		return
+	}
+	c.JSON(http.StatusOK, hold)
+}
+
+// authorizeHold loads a hold and checks the caller may use its account
+func authorizeHold(c *gin.Context, holdID uint) (*balance.Hold, bool) {
+	hold, err := getHold(holdID)
+	if err != nil {
+		respondHoldError(c, err, nil)
+		return nil, false
+	}
+	if _, ok := authorizeAccount(c, hold.AccountID); !ok {
+		return nil, false
+	}
+	return hold, true
+}
+
+func respondHoldError(c *gin.Context, err error, tx *Transaction) {
+	switch {
+	case errors.Is(err, balance.ErrHoldNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "provizyon bulunamadı"})
+	case errors.Is(err, balance.ErrHoldNotActive):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "transaction": tx})
+	}
+}
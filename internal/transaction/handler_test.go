@@ -0,0 +1,81 @@
+package transaction
+
+import (
+	"bankapi/internal/account"
+	"bankapi/internal/balance"
+	"bankapi/internal/middleware"
+	"bankapi/internal/user"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Account 1 belongs to user 1 and holds hold 10. The handlers must reject
+// every other user before they touch the database, which is not set up here.
+func TestHoldHandlersRejectOtherUsersAccounts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	getAccount = func(id uint) (*account.Account, error) {
+		if id != 1 {
+			return nil, account.ErrAccountNotFound
+		}
+		return &account.Account{ID: 1, UserID: 1, Status: account.StatusActive}, nil
+	}
+	getHold = func(id uint) (*balance.Hold, error) {
+		if id != 10 {
+			return nil, balance.ErrHoldNotFound
+		}
+		return &balance.Hold{ID: 10, AccountID: 1, Status: balance.HoldStatusActive}, nil
+	}
+	t.Cleanup(func() {
+		getAccount = account.Get
+		getHold = balance.GetHold
+	})
+
+	router := func(u user.User) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) { c.Set(middleware.ContextUserKey, u) })
+		r.POST("/holds", handlePlaceHold)
+		r.GET("/holds", handleListHolds)
+		r.GET("/holds/:id", handleGetHold)
+		r.POST("/holds/:id/capture", handleCaptureHold)
+		r.POST("/holds/:id/void", handleVoidHold)
+		return r
+	}
+	other := router(user.User{ID: 2, Role: "user"})
+
+	tests := []struct {
+		name, method, path, body string
+		want                     int
+	}{
+		{"place", http.MethodPost, "/holds", `{"account_id":1,"amount_cents":500}`, http.StatusForbidden},
+		{"list", http.MethodGet, "/holds?account_id=1", "", http.StatusForbidden},
+		{"get", http.MethodGet, "/holds/10", "", http.StatusForbidden},
+		{"capture", http.MethodPost, "/holds/10/capture", "", http.StatusForbidden},
+		{"void", http.MethodPost, "/holds/10/void", "", http.StatusForbidden},
+		{"unknown account", http.MethodGet, "/holds?account_id=2", "", http.StatusNotFound},
+		{"unknown hold", http.MethodPost, "/holds/11/void", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			other.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("%s %s = %d %s, want %d", tt.method, tt.path, w.Code, w.Body.String(), tt.want)
+			}
+		})
+	}
+
+	// The owner and admins get past the check
+	for _, u := range []user.User{{ID: 1, Role: "user"}, {ID: 3, Role: "admin"}} {
+		w := httptest.NewRecorder()
+		router(u).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/holds/10", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /holds/10 as user %d = %d %s, want 200", u.ID, w.Code, w.Body.String())
+		}
+	}
+}
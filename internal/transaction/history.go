@@ -0,0 +1,182 @@
+package transaction
+
+import (
+	"bankapi/internal/db"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 200
+)
+
+// HistoryFilter holds the optional filters of a history query
+type HistoryFilter struct {
+	UserID    *uint
+	AccountID *uint
+	Type      TransactionType
+	Status    TransactionStatus
+	MinAmount *int64
+	MaxAmount *int64
+	From      *time.Time
+	To        *time.Time
+	Limit     int
+	Cursor    *HistoryCursor
+}
+
+// HistoryCursor is the keyset position (created_at, id) of the last row seen
+type HistoryCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uint      `json:"id"`
+}
+
+// Encode returns the opaque cursor string handed to clients
+func (c HistoryCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*HistoryCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var c HistoryCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == 0 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &c, nil
+}
+
+// ParseHistoryFilter reads the filter from query parameters
+func ParseHistoryFilter(q url.Values) (HistoryFilter, error) {
+	f := HistoryFilter{Limit: defaultHistoryLimit}
+
+	if v := q.Get("user_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || id == 0 {
+			return f, fmt.Errorf("user_id geçersiz")
+		}
+		uid := uint(id)
+		f.UserID = &uid
+	}
+	if v := q.Get("account_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || id == 0 {
+			return f, fmt.Errorf("account_id geçersiz")
+		}
+		aid := uint(id)
+		f.AccountID = &aid
+	}
+	if v := q.Get("type"); v != "" {
+		f.Type = TransactionType(v)
+	}
+	if v := q.Get("status"); v != "" {
+		f.Status = TransactionStatus(v)
+	}
+	if v := q.Get("min_amount"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return f, fmt.Errorf("min_amount geçersiz")
+		}
+		f.MinAmount = &n
+	}
+	if v := q.Get("max_amount"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return f, fmt.Errorf("max_amount geçersiz")
+		}
+		f.MaxAmount = &n
+	}
+	if v := q.Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return f, fmt.Errorf("from tarih formatı RFC3339 olmalı")
+		}
+		f.From = &t
+	}
+	if v := q.Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return f, fmt.Errorf("to tarih formatı RFC3339 olmalı")
+		}
+		f.To = &t
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return f, fmt.Errorf("limit geçersiz")
+		}
+		if n > maxHistoryLimit {
+			n = maxHistoryLimit
+		}
+		f.Limit = n
+	}
+	if v := q.Get("cursor"); v != "" {
+		c, err := decodeCursor(v)
+		if err != nil {
+			return f, err
+		}
+		f.Cursor = c
+	}
+	return f, nil
+}
+
+// apply adds the filter conditions to query
+func (f HistoryFilter) apply(query *gorm.DB) *gorm.DB {
+	if f.UserID != nil {
+		query = query.Where("(from_user_id = ? OR to_user_id = ?)", *f.UserID, *f.UserID)
+	}
+	if f.AccountID != nil {
+		query = query.Where("(from_account_id = ? OR to_account_id = ?)", *f.AccountID, *f.AccountID)
+	}
+	if f.Type != "" {
+		query = query.Where("type = ?", f.Type)
+	}
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+	if f.MinAmount != nil {
+		query = query.Where("amount_cents >= ?", *f.MinAmount)
+	}
+	if f.MaxAmount != nil {
+		query = query.Where("amount_cents <= ?", *f.MaxAmount)
+	}
+	if f.From != nil {
+		query = query.Where("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("created_at < ?", *f.To)
+	}
+	if f.Cursor != nil {
+		query = query.Where("(created_at, id) < (?, ?)", f.Cursor.CreatedAt, f.Cursor.ID)
+	}
+	return query
+}
+
+// QueryHistory returns one page of transactions, newest first, and the cursor
+// of the next page (nil when there are no more rows).
+func QueryHistory(f HistoryFilter) ([]Transaction, *HistoryCursor, error) {
+	var txs []Transaction
+	err := f.apply(db.DB.Model(&Transaction{})).
+		Order("created_at DESC, id DESC").
+		Limit(f.Limit + 1).
+		Find(&txs).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query transaction history: %w", err)
+	}
+
+	if len(txs) <= f.Limit {
+		return txs, nil, nil
+	}
+	txs = txs[:f.Limit]
+	last := txs[len(txs)-1]
+	return txs, &HistoryCursor{CreatedAt: last.CreatedAt, ID: last.ID}, nil
+}
@@ -0,0 +1,65 @@
+package transaction
+
+import (
+	"bankapi/internal/audit"
+	"bankapi/internal/balance"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// CaptureHold settles an active hold as a debit transaction linked to it.
+// amount zero captures the full hold; a partial capture releases the rest.
+func CaptureHold(holdID uint, amount int64) (*Transaction, error) {
+	println("💳 Provizyon tahsil ediliyor, ID:", holdID, "miktar:", amount)
+
+	hold, err := balance.GetHold(holdID)
+	if err != nil {
+		return nil, err
+	}
+	if !hold.IsActive() {
+		return nil, fmt.Errorf("%w: status=%s", balance.ErrHoldNotActive, hold.Status)
+	}
+	if amount == 0 {
+		amount = hold.AmountCents
+	}
+	if amount < 0 || amount > hold.AmountCents {
+		return nil, fmt.Errorf("capture amount must be between 1 and %d", hold.AmountCents)
+	}
+
+	accountID := hold.AccountID
+	tx := &Transaction{
+		AmountCents: amount,
+		Currency:    hold.Currency,
+		Type:        TransactionTypeDebit,
+		Status:      TransactionStatusPending,
+		HoldID:      &hold.ID,
+	}
+	// The funds were reserved while the account could pay out, so a hold is
+	// captured even if the account has been frozen since
+	if err := bindAccounts(tx, &accountID, nil, false); err != nil {
+		return nil, err
+	}
+
+	err = execute(tx, func(dbTx *gorm.DB) error {
+		// Lock order is always balance first, then hold
+		if _, err := balance.LockBalances(dbTx, balance.Key{AccountID: accountID, Currency: hold.Currency}); err != nil {
+			return err
+		}
+		// Closing the hold first releases its reservation for the debit below
+		if _, err := balance.CaptureHoldTx(dbTx, holdID, amount); err != nil {
+			return err
+		}
+		if err := move(dbTx, tx, &accountID, nil); err != nil {
+			return err
+		}
+		return audit.LogTx(dbTx, "transaction", fmt.Sprintf("%d", tx.ID), "capture", fmt.Sprintf("hold=%d from=%d amount=%d %s", holdID, accountID, amount, hold.Currency))
+	})
+	if err != nil {
+		println("❌ Provizyon tahsilatı başarısız:", err.Error())
+		return tx, err
+	}
+
+	println("✅ Provizyon tahsil edildi, transaction ID:", tx.ID)
+	return tx, nil
+}
@@ -0,0 +1,130 @@
+package transaction
+
+import (
+	"bankapi/internal/audit"
+	"bankapi/internal/balance"
+	"bankapi/internal/ledger"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ApplyInterest credits gross interest to accountID out of the bank's interest
+// expense and withholds tax as a separate withholding_tax transaction linked
+// to the interest credit and posted to the tax payable account. settle runs
+// in the same database transaction once the money has moved (taxTx is nil
+// when nothing was withheld), so callers can mark what was paid without
+// risking a double payment.
+func ApplyInterest(accountID uint, cur string, gross, tax int64, settle func(dbTx *gorm.DB, interestTx, taxTx *Transaction) error) (*Transaction, error) {
+	println("🏦 Faiz işlemi uygulanıyor, hesap ID:", accountID, "brüt:", gross, "stopaj:", tax, cur)
+
+	if gross <= 0 {
+		return nil, fmt.Errorf("interest amount must be positive")
+	}
+	if tax < 0 || tax > gross {
+		return nil, fmt.Errorf("withholding tax must be between 0 and the interest amount")
+	}
+	if err := checkCurrency(cur); err != nil {
+		return nil, err
+	}
+
+	txModel := &Transaction{AmountCents: gross, Currency: cur, Type: TransactionTypeInterest, Status: TransactionStatusPending}
+	if err := bindAccounts(txModel, nil, &accountID, false); err != nil {
+		return nil, err
+	}
+
+	err := execute(txModel, func(dbTx *gorm.DB) error {
+		if err := balance.CreditTx(dbTx, accountID, cur, gross, txModel.entry()); err != nil {
+			return err
+		}
+		if _, err := ledger.PostTx(dbTx, &txModel.ID, string(TransactionTypeInterest),
+			ledger.Debit(ledger.AccountInterestExpense, cur, gross),
+			ledger.Credit(ledger.CustomerAccount(accountID), cur, gross),
+		); err != nil {
+			return err
+		}
+
+		var taxTx *Transaction
+		if tax > 0 {
+			taxTx = &Transaction{
+				FromAccountID:       txModel.ToAccountID,
+				FromUserID:          txModel.ToUserID,
+				AmountCents:         tax,
+				Currency:            cur,
+				Type:                TransactionTypeWithholdingTax,
+				Status:              TransactionStatusCompleted,
+				ParentTransactionID: &txModel.ID,
+			}
+			if err := dbTx.Create(taxTx).Error; err != nil {
+				return fmt.Errorf("failed to create withholding tax transaction: %w", err)
+			}
+			// Withholding is a charge on interest just credited; holds or a
+			// lowered credit limit must not make the capitalization fail
+			if err := balance.ChargeTx(dbTx, accountID, cur, tax, taxTx.entry()); err != nil {
+				return err
+			}
+			if _, err := ledger.PostTx(dbTx, &taxTx.ID, string(TransactionTypeWithholdingTax),
+				ledger.Debit(ledger.CustomerAccount(accountID), cur, tax),
+				ledger.Credit(ledger.AccountTaxPayable, cur, tax),
+			); err != nil {
+				return err
+			}
+		}
+
+		if settle != nil {
+			if err := settle(dbTx, txModel, taxTx); err != nil {
+				return err
+			}
+		}
+		return audit.LogTx(dbTx, "transaction", fmt.Sprintf("%d", txModel.ID), "interest", fmt.Sprintf("to=%d gross=%d tax=%d %s", accountID, gross, tax, cur))
+	})
+	if err != nil {
+		return txModel, err
+	}
+
+	println("✅ Faiz işlemi tamamlandı, transaction ID:", txModel.ID)
+	return txModel, nil
+}
+
+// ChargeOverdraftInterest debits overdraft interest from accountID to the bank's
+// interest income. The charge is booked even when it takes the balance past
+// its credit limit. settle runs in the same database transaction.
+func ChargeOverdraftInterest(accountID uint, cur string, amount int64, settle func(dbTx *gorm.DB, txModel *Transaction) error) (*Transaction, error) {
+	println("🏦 Kredili mevduat faizi uygulanıyor, hesap ID:", accountID, "miktar:", amount, cur)
+
+	if amount <= 0 {
+		return nil, fmt.Errorf("overdraft interest must be positive")
+	}
+	if err := checkCurrency(cur); err != nil {
+		return nil, err
+	}
+
+	txModel := &Transaction{AmountCents: amount, Currency: cur, Type: TransactionTypeOverdraftInterest, Status: TransactionStatusPending}
+	if err := bindAccounts(txModel, &accountID, nil, false); err != nil {
+		return nil, err
+	}
+
+	err := execute(txModel, func(dbTx *gorm.DB) error {
+		if err := balance.ChargeTx(dbTx, accountID, cur, amount, txModel.entry()); err != nil {
+			return err
+		}
+		if _, err := ledger.PostTx(dbTx, &txModel.ID, string(TransactionTypeOverdraftInterest),
+			ledger.Debit(ledger.CustomerAccount(accountID), cur, amount),
+			ledger.Credit(ledger.AccountInterestIncome, cur, amount),
+		); err != nil {
+			return err
+		}
+		if settle != nil {
+			if err := settle(dbTx, txModel); err != nil {
+				return err
+			}
+		}
+		return audit.LogTx(dbTx, "transaction", fmt.Sprintf("%d", txModel.ID), "overdraft_interest", fmt.Sprintf("from=%d amount=%d %s", accountID, amount, cur))
+	})
+	if err != nil {
+		return txModel, err
+	}
+
+	println("✅ Kredili mevduat faizi tahsil edildi, transaction ID:", txModel.ID)
+	return txModel, nil
+}
@@ -0,0 +1,30 @@
+package transaction
+
+import (
+	"bankapi/internal/limits"
+	"bankapi/internal/user"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// payerRole returns the role used for pricing and limits. Unknown users are
+// treated as plain users.
+func payerRole(dbTx *gorm.DB, userID uint) (string, error) {
+	var payer user.User
+	if err := dbTx.Select("id", "role").Limit(1).Find(&payer, userID).Error; err != nil {
+		return "", fmt.Errorf("failed to load payer: %w", err)
+	}
+	if payer.Role == "" {
+		return "user", nil
+	}
+	return payer.Role, nil
+}
+
+// checkLimits enforces the payer's per-transaction, daily and monthly limits
+// for txModel. It runs after the payer's balances are locked; CheckTx then
+// takes the payer's usage lock, so debits from any of their accounts are
+// checked one at a time.
+func checkLimits(dbTx *gorm.DB, txModel *Transaction, payerID uint, role string) error {
+	return limits.CheckTx(dbTx, payerID, role, string(txModel.Type), txModel.Currency, txModel.AmountCents, txModel.ID)
+}
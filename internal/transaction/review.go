@@ -0,0 +1,156 @@
+package transaction
+
+import (
+	"bankapi/internal/audit"
+	"bankapi/internal/db"
+	"bankapi/internal/fraud"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// holdForReview stores a transfer as pending_review together with the fraud
+// decision that held it. No money moves until an admin approves it.
+func holdForReview(txModel *Transaction, decision *fraud.Decision) error {
+	txModel.Status = TransactionStatusPendingReview
+	return db.DB.Transaction(func(dbTx *gorm.DB) error {
+		if err := dbTx.Create(txModel).Error; err != nil {
+			println("❌ Transaction oluşturulamadı:", err.Error())
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+		if err := fraud.SaveTx(dbTx, decision, txModel.ID); err != nil {
+			return err
+		}
+		return audit.LogTx(dbTx, "transaction", fmt.Sprintf("%d", txModel.ID), "fraud_review", fmt.Sprintf("score=%d", decision.Score))
+	})
+}
+
+// saveDecision persists the screening decision for a transfer that was
+// executed or blocked. It is best effort: the transfer outcome stands even
+// if the decision cannot be written.
+func saveDecision(decision *fraud.Decision, txModel *Transaction) {
+	if txModel.ID == 0 {
+		return
+	}
+	if err := fraud.SaveTx(db.DB, decision, txModel.ID); err != nil {
+		println("⚠️ Fraud kararı kaydedilemedi:", err.Error())
+	}
+}
+
+// ApproveReview releases a transfer held for review and settles it. Balance,
+// limits and fees are evaluated at approval time; if settlement fails the
+// transfer is marked failed and the review stays approved.
+func ApproveReview(id uint, reviewerID *uint, note string) (*Transaction, error) {
+	println("✅ İncelenen transfer onaylanıyor, ID:", id)
+
+	txModel, err := loadForReview(id)
+	if err != nil {
+		return txModel, err
+	}
+
+	if err := resolveReview(id, fraud.ReviewStatusApproved, reviewerID, note); err != nil {
+		return txModel, err
+	}
+
+	err = db.DB.Transaction(func(dbTx *gorm.DB) error {
+		if err := dbTx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).First(txModel, id).Error; err != nil {
+			return fmt.Errorf("failed to lock transaction: %w", err)
+		}
+		if txModel.Status != TransactionStatusPendingReview {
+			return fraud.ErrNotUnderReview
+		}
+		if err := transferApply(txModel)(dbTx); err != nil {
+			return err
+		}
+		if err := txModel.MarkAsCompleted(); err != nil {
+			return err
+		}
+		if err := dbTx.Save(txModel).Error; err != nil {
+			return fmt.Errorf("failed to update transaction: %w", err)
+		}
+		return emitTx(dbTx, txModel, EventCompleted)
+	})
+	if err != nil {
+		if !errors.Is(err, fraud.ErrNotUnderReview) {
+			failReviewed(txModel, err)
+		}
+		return txModel, err
+	}
+
+	println("✅ İncelenen transfer tamamlandı, transaction ID:", txModel.ID)
+	return txModel, nil
+}
+
+// RejectReview closes the review and marks the transfer rejected
+func RejectReview(id uint, reviewerID *uint, note string) (*Transaction, error) {
+	println("⛔ İncelenen transfer reddediliyor, ID:", id)
+
+	txModel, err := loadForReview(id)
+	if err != nil {
+		return txModel, err
+	}
+
+	err = db.DB.Transaction(func(dbTx *gorm.DB) error {
+		decision, err := fraud.LockOpenReviewTx(dbTx, id)
+		if err != nil {
+			return err
+		}
+		if err := dbTx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).First(txModel, id).Error; err != nil {
+			return fmt.Errorf("failed to lock transaction: %w", err)
+		}
+		if err := txModel.TransitionTo(TransactionStatusRejected); err != nil {
+			return fraud.ErrNotUnderReview
+		}
+		txModel.FailureCause = note
+		if err := dbTx.Save(txModel).Error; err != nil {
+			return fmt.Errorf("failed to update transaction: %w", err)
+		}
+		if err := fraud.ResolveTx(dbTx, decision, fraud.ReviewStatusRejected, reviewerID, note); err != nil {
+			return err
+		}
+		return audit.LogTx(dbTx, "transaction", fmt.Sprintf("%d", id), "fraud_reject", note)
+	})
+	if err != nil {
+		return txModel, err
+	}
+	return txModel, nil
+}
+
+func loadForReview(id uint) (*Transaction, error) {
+	var txModel Transaction
+	if err := db.DB.First(&txModel, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, fmt.Errorf("failed to load transaction: %w", err)
+	}
+	if txModel.Status != TransactionStatusPendingReview {
+		return &txModel, fraud.ErrNotUnderReview
+	}
+	return &txModel, nil
+}
+
+// resolveReview closes the open review first, so two admins acting on the
+// same transfer cannot both settle it.
+func resolveReview(id uint, status fraud.ReviewStatus, reviewerID *uint, note string) error {
+	return db.DB.Transaction(func(dbTx *gorm.DB) error {
+		decision, err := fraud.LockOpenReviewTx(dbTx, id)
+		if err != nil {
+			return err
+		}
+		if err := fraud.ResolveTx(dbTx, decision, status, reviewerID, note); err != nil {
+			return err
+		}
+		return audit.LogTx(dbTx, "transaction", fmt.Sprintf("%d", id), "fraud_"+string(status), note)
+	})
+}
+
+// failReviewed marks an approved transfer failed after its settlement was
+// rolled back
+func failReviewed(txModel *Transaction, cause error) {
+	if err := markFailed(txModel, TransactionStatusPendingReview, cause); err != nil {
+		println("⚠️ Failed transaction kaydedilemedi:", err.Error())
+	}
+}
@@ -0,0 +1,274 @@
+package transaction
+
+import (
+	"bankapi/internal/audit"
+	"bankapi/internal/currency"
+	"bankapi/internal/db"
+	"bankapi/internal/fraud"
+	"bankapi/internal/logger"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxExecuteAhead is how far in the future a transfer can be value-dated
+const MaxExecuteAhead = 365 * 24 * time.Hour
+
+// dispatchBatch bounds the due transfers run per dispatcher pass
+const dispatchBatch = 500
+
+var (
+	ErrNotCancellable = errors.New("only scheduled transfers that have not run can be cancelled")
+	// errNoLongerPending is a scheduled transfer cancelled or run meanwhile
+	errNoLongerPending = errors.New("scheduled transfer is no longer pending")
+)
+
+// ScheduleTransfer stores a transfer to run at executeAt as a pending
+// transaction. Accounts and currencies are checked now; the FX rate,
+// screening, balance, limits and fee are evaluated when it runs.
+func ScheduleTransfer(fromID, toID uint, fromCur, toCur string, amount int64, executeAt time.Time) (*Transaction, error) {
+	println("📅 Transfer planlanıyor, from:", fromID, "to:", toID, "miktar:", amount, fromCur, "zaman:", executeAt.Format(time.RFC3339))
+
+	if amount <= 0 {
+		return nil, fmt.Errorf("transfer amount must be positive")
+	}
+	now := time.Now()
+	if !executeAt.After(now) {
+		return nil, fmt.Errorf("execute_at must be in the future")
+	}
+	if executeAt.After(now.Add(MaxExecuteAhead)) {
+		return nil, fmt.Errorf("execute_at must be within %d days", int(MaxExecuteAhead.Hours()/24))
+	}
+	if err := checkCurrency(fromCur); err != nil {
+		return nil, err
+	}
+	if err := checkCurrency(toCur); err != nil {
+		return nil, err
+	}
+	if fromID == toID && fromCur == toCur {
+		return nil, fmt.Errorf("cannot transfer to same account")
+	}
+
+	txModel := &Transaction{AmountCents: amount, Currency: fromCur, Type: TransactionTypeTransfer, Status: TransactionStatusPending, ExecuteAt: &executeAt}
+	if fromCur != toCur {
+		txModel.ToCurrency = toCur
+	}
+	if err := bindAccounts(txModel, &fromID, &toID, true); err != nil {
+		println("❌ Hesap kullanılamıyor:", err.Error())
+		return nil, err
+	}
+
+	err := db.DB.Transaction(func(dbTx *gorm.DB) error {
+		if err := dbTx.Create(txModel).Error; err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+		return audit.LogTx(dbTx, "transaction", fmt.Sprintf("%d", txModel.ID), "schedule", fmt.Sprintf("from=%d to=%d amount=%d %s at=%s", fromID, toID, amount, fromCur, executeAt.Format(time.RFC3339)))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	println("✅ Transfer planlandı, transaction ID:", txModel.ID)
+	return txModel, nil
+}
+
+// DispatchDueTransfers runs every scheduled transfer whose value date has
+// come. Each runs on its own; one failing does not stop the rest.
+func DispatchDueTransfers() (int, error) {
+	var ids []uint
+	if err := db.DB.Model(&Transaction{}).
+		Where("status = ? AND execute_at IS NOT NULL AND execute_at <= ?", TransactionStatusPending, time.Now()).
+		Order("execute_at, id").
+		Limit(dispatchBatch).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("failed to load due transfers: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	println("📅 Vadesi gelen transferler çalıştırılıyor:", len(ids))
+	completed := 0
+	for _, id := range ids {
+		txModel, err := ExecuteScheduled(id)
+		if err == nil && txModel.Status == TransactionStatusCompleted {
+			completed++
+		}
+	}
+
+	logger.Info("Scheduled transfers dispatched", map[string]interface{}{
+		"due":       len(ids),
+		"completed": completed,
+	})
+	return completed, nil
+}
+
+// ExecuteScheduled settles a due scheduled transfer. The accounts, FX rate,
+// screening, balance, limits and fee are all evaluated now; if settlement
+// fails the transfer is marked failed with the cause so it shows up in
+// history.
+func ExecuteScheduled(id uint) (*Transaction, error) {
+	println("🚀 Planlanan transfer çalıştırılıyor, ID:", id)
+
+	var txModel Transaction
+	if err := db.DB.First(&txModel, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, fmt.Errorf("failed to load transaction: %w", err)
+	}
+	if !txModel.IsScheduled() {
+		return &txModel, errNoLongerPending
+	}
+
+	fromID, toID := *txModel.FromAccountID, *txModel.ToAccountID
+	if err := bindAccounts(&txModel, &fromID, &toID, true); err != nil {
+		failScheduled(&txModel, err)
+		return &txModel, err
+	}
+	if txModel.IsFX() {
+		quote, err := currency.Default().Quote(txModel.AmountCents, txModel.Currency, txModel.ToCurrency)
+		if err != nil {
+			err = fmt.Errorf("failed to quote %s/%s: %w", txModel.Currency, txModel.ToCurrency, err)
+			failScheduled(&txModel, err)
+			return &txModel, err
+		}
+		txModel.ToAmountCents = quote.ToAmountCents
+		txModel.FXRate = quote.MidRate
+		txModel.FXSpreadBps = quote.SpreadBps
+		txModel.FXAppliedRate = quote.AppliedRate
+	}
+
+	// Screening errors leave the transfer pending for the next pass
+	decision, err := fraud.Default().Screen(db.DB, fraud.Candidate{
+		Type:        string(TransactionTypeTransfer),
+		FromUserID:  *txModel.FromUserID,
+		ToUserID:    *txModel.ToUserID,
+		Currency:    txModel.Currency,
+		AmountCents: txModel.AmountCents,
+	})
+	if err != nil {
+		println("❌ Fraud taraması başarısız:", err.Error())
+		return &txModel, err
+	}
+	switch decision.Outcome {
+	case fraud.OutcomeBlock:
+		failScheduled(&txModel, fraud.ErrBlocked)
+		saveDecision(decision, &txModel)
+		return &txModel, fraud.ErrBlocked
+	case fraud.OutcomeReview:
+		if err := reviewScheduled(&txModel, decision); err != nil {
+			return &txModel, err
+		}
+		println("⏸️ Planlanan transfer incelemeye alındı, transaction ID:", txModel.ID)
+		return &txModel, nil
+	}
+
+	err = db.DB.Transaction(func(dbTx *gorm.DB) error {
+		if err := lockPending(dbTx, id); err != nil {
+			return err
+		}
+		if err := transferApply(&txModel)(dbTx); err != nil {
+			return err
+		}
+		if err := txModel.MarkAsCompleted(); err != nil {
+			return err
+		}
+		if err := dbTx.Save(&txModel).Error; err != nil {
+			return fmt.Errorf("failed to update transaction: %w", err)
+		}
+		return emitTx(dbTx, &txModel, EventCompleted)
+	})
+	saveDecision(decision, &txModel)
+	if err != nil {
+		if !errors.Is(err, errNoLongerPending) {
+			failScheduled(&txModel, err)
+		}
+		return &txModel, err
+	}
+
+	println("✅ Planlanan transfer tamamlandı, transaction ID:", txModel.ID)
+	return &txModel, nil
+}
+
+// CancelScheduled cancels a scheduled transfer that has not run yet. A
+// non-nil userID must be the payer; other users' transfers are reported as
+// not found.
+func CancelScheduled(id uint, userID *uint) (*Transaction, error) {
+	println("🛑 Planlanan transfer iptal ediliyor, ID:", id)
+
+	var txModel Transaction
+	err := db.DB.Transaction(func(dbTx *gorm.DB) error {
+		if err := dbTx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).First(&txModel, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTransactionNotFound
+			}
+			return fmt.Errorf("failed to lock transaction: %w", err)
+		}
+		if userID != nil && (txModel.FromUserID == nil || *txModel.FromUserID != *userID) {
+			return ErrTransactionNotFound
+		}
+		if !txModel.IsScheduled() {
+			return ErrNotCancellable
+		}
+		if err := txModel.TransitionTo(TransactionStatusCancelled); err != nil {
+			return err
+		}
+		if err := dbTx.Save(&txModel).Error; err != nil {
+			return fmt.Errorf("failed to update transaction: %w", err)
+		}
+		return audit.LogTx(dbTx, "transaction", fmt.Sprintf("%d", id), "cancel", fmt.Sprintf("execute_at=%s", txModel.ExecuteAt.Format(time.RFC3339)))
+	})
+	if err != nil {
+		return &txModel, err
+	}
+	return &txModel, nil
+}
+
+// lockPending locks a scheduled transfer row and makes sure it has not been
+// cancelled or run since it was loaded
+func lockPending(dbTx *gorm.DB, id uint) error {
+	var current Transaction
+	if err := dbTx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Select("id", "status").First(&current, id).Error; err != nil {
+		return fmt.Errorf("failed to lock transaction: %w", err)
+	}
+	if current.Status != TransactionStatusPending {
+		return errNoLongerPending
+	}
+	return nil
+}
+
+// reviewScheduled hands a due transfer to fraud review. It runs when an
+// admin approves it.
+func reviewScheduled(txModel *Transaction, decision *fraud.Decision) error {
+	return db.DB.Transaction(func(dbTx *gorm.DB) error {
+		if err := lockPending(dbTx, txModel.ID); err != nil {
+			return err
+		}
+		if err := txModel.TransitionTo(TransactionStatusPendingReview); err != nil {
+			return err
+		}
+		if err := dbTx.Save(txModel).Error; err != nil {
+			return fmt.Errorf("failed to update transaction: %w", err)
+		}
+		if err := fraud.SaveTx(dbTx, decision, txModel.ID); err != nil {
+			return err
+		}
+		return audit.LogTx(dbTx, "transaction", fmt.Sprintf("%d", txModel.ID), "fraud_review", fmt.Sprintf("score=%d", decision.Score))
+	})
+}
+
+// failScheduled marks a scheduled transfer failed after its settlement was
+// rolled back, unless it was cancelled or run meanwhile
+func failScheduled(txModel *Transaction, cause error) {
+	logger.Error("Scheduled transfer failed", cause, map[string]interface{}{
+		"transaction_id": txModel.ID,
+	})
+	if err := markFailed(txModel, TransactionStatusPending, cause); err != nil {
+		println("⚠️ Failed transaction kaydedilemedi:", err.Error())
+	}
+}
@@ -3,148 +3,298 @@ package transaction
 import (
 	"bankapi/internal/audit"
 	"bankapi/internal/balance"
+	"bankapi/internal/currency"
 	"bankapi/internal/db"
+	"bankapi/internal/fraud"
+	"bankapi/internal/ledger"
 	"fmt"
+
+	"gorm.io/gorm"
 )
 
-func ApplyCredit(userID uint, amount int64) (*Transaction, error) {
-	println("💳 Kredi işlemi uygulanıyor, kullanıcı ID:", userID, "miktar:", amount, "kuruş")
+func ApplyCredit(accountID uint, cur string, amount int64) (*Transaction, error) {
+	println("💳 Kredi işlemi uygulanıyor, hesap ID:", accountID, "miktar:", amount, cur)
 
 	if amount <= 0 {
 		println("❌ Geçersiz kredi miktarı:", amount)
 		return nil, fmt.Errorf("credit amount must be positive")
 	}
-
-	tx := &Transaction{ToUserID: &userID, AmountCents: amount, Type: TransactionTypeCredit, Status: TransactionStatusPending}
-
-	if err := db.DB.Create(tx).Error; err != nil {
-		println("❌ Transaction oluşturulamadı:", err.Error())
-		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	if err := checkCurrency(cur); err != nil {
+		return nil, err
 	}
 
-	println("📝 Transaction oluşturuldu, ID:", tx.ID)
+	tx := &Transaction{AmountCents: amount, Currency: cur, Type: TransactionTypeCredit, Status: TransactionStatusPending}
+	if err := bindAccounts(tx, nil, &accountID, true); err != nil {
+		println("❌ Hesap kullanılamıyor:", err.Error())
+		return nil, err
+	}
 
-	if err := balance.Credit(userID, amount); err != nil {
-		println("❌ Bakiye kredisi başarısız:", err.Error())
-		tx.Status = TransactionStatusFailed
-		tx.FailureCause = err.Error()
-		if saveErr := db.DB.Save(tx).Error; saveErr != nil {
-			println("⚠️ Failed transaction kaydedilemedi:", saveErr.Error())
+	err := execute(tx, func(dbTx *gorm.DB) error {
+		if err := move(dbTx, tx, nil, &accountID); err != nil {
+			println("❌ Bakiye kredisi başarısız:", err.Error())
+			return err
 		}
+		return audit.LogTx(dbTx, "transaction", fmt.Sprintf("%d", tx.ID), "credit", fmt.Sprintf("to=%d amount=%d %s", accountID, amount, cur))
+	})
+	if err != nil {
 		return tx, err
 	}
 
-	tx.Status = TransactionStatusCompleted
-	if err := db.DB.Save(tx).Error; err != nil {
-		println("❌ Transaction güncellenemedi:", err.Error())
-		return tx, fmt.Errorf("failed to update transaction: %w", err)
-	}
-
-	audit.Log("transaction", fmt.Sprintf("%d", tx.ID), "credit", fmt.Sprintf("to=%d amount=%d", userID, amount))
 	println("✅ Kredi işlemi başarıyla tamamlandı, transaction ID:", tx.ID)
 	return tx, nil
 }
 
-func ApplyDebit(userID uint, amount int64) (*Transaction, error) {
-	println("💸 Debit işlemi uygulanıyor, kullanıcı ID:", userID, "miktar:", amount, "kuruş")
+func ApplyDebit(accountID uint, cur string, amount int64) (*Transaction, error) {
+	println("💸 Debit işlemi uygulanıyor, hesap ID:", accountID, "miktar:", amount, cur)
 
 	if amount <= 0 {
 		println("❌ Geçersiz debit miktarı:", amount)
 		return nil, fmt.Errorf("debit amount must be positive")
 	}
-
-	tx := &Transaction{FromUserID: &userID, AmountCents: amount, Type: TransactionTypeDebit, Status: TransactionStatusPending}
-
-	if err := db.DB.Create(tx).Error; err != nil {
-		println("❌ Transaction oluşturulamadı:", err.Error())
-		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	if err := checkCurrency(cur); err != nil {
+		return nil, err
 	}
 
-	println("📝 Transaction oluşturuldu, ID:", tx.ID)
+	tx := &Transaction{AmountCents: amount, Currency: cur, Type: TransactionTypeDebit, Status: TransactionStatusPending}
+	if err := bindAccounts(tx, &accountID, nil, true); err != nil {
+		println("❌ Hesap kullanılamıyor:", err.Error())
+		return nil, err
+	}
+	userID := *tx.FromUserID
 
-	if err := balance.Debit(userID, amount); err != nil {
-		println("❌ Bakiye debiti başarısız:", err.Error())
-		tx.Status = TransactionStatusFailed
-		tx.FailureCause = err.Error()
-		if saveErr := db.DB.Save(tx).Error; saveErr != nil {
-			println("⚠️ Failed transaction kaydedilemedi:", saveErr.Error())
+	err := execute(tx, func(dbTx *gorm.DB) error {
+		role, err := payerRole(dbTx, userID)
+		if err != nil {
+			return err
+		}
+		if err := move(dbTx, tx, &accountID, nil); err != nil {
+			println("❌ Bakiye debiti başarısız:", err.Error())
+			return err
+		}
+		if err := checkLimits(dbTx, tx, userID, role); err != nil {
+			return err
 		}
+		if err := chargeFee(dbTx, tx, role); err != nil {
+			return err
+		}
+		return audit.LogTx(dbTx, "transaction", fmt.Sprintf("%d", tx.ID), "debit", fmt.Sprintf("from=%d amount=%d %s", accountID, amount, cur))
+	})
+	if err != nil {
 		return tx, err
 	}
 
-	tx.Status = TransactionStatusCompleted
-	if err := db.DB.Save(tx).Error; err != nil {
-		println("❌ Transaction güncellenemedi:", err.Error())
-		return tx, fmt.Errorf("failed to update transaction: %w", err)
-	}
-
-	audit.Log("transaction", fmt.Sprintf("%d", tx.ID), "debit", fmt.Sprintf("from=%d amount=%d", userID, amount))
 	println("✅ Debit işlemi başarıyla tamamlandı, transaction ID:", tx.ID)
 	return tx, nil
 }
 
-func ApplyTransfer(fromID, toID uint, amount int64) (*Transaction, error) {
-	println("🔄 Transfer işlemi uygulanıyor, from:", fromID, "to:", toID, "miktar:", amount, "kuruş")
+// ApplyTransfer moves amount of fromCur from account fromID to account toID. When toCur
+// differs the amount is converted through the currency service and the
+// applied rate, spread and both legs are recorded on the transaction.
+func ApplyTransfer(fromID, toID uint, fromCur, toCur string, amount int64) (*Transaction, error) {
+	println("🔄 Transfer işlemi uygulanıyor, from:", fromID, "to:", toID, "miktar:", amount, fromCur, "->", toCur)
 
 	if amount <= 0 {
 		println("❌ Geçersiz transfer miktarı:", amount)
 		return nil, fmt.Errorf("transfer amount must be positive")
 	}
-
-	if fromID == toID {
-		println("❌ Aynı kullanıcıya transfer yapılamaz")
-		return nil, fmt.Errorf("cannot transfer to same user")
+	if err := checkCurrency(fromCur); err != nil {
+		return nil, err
+	}
+	if err := checkCurrency(toCur); err != nil {
+		return nil, err
 	}
 
-	txModel := &Transaction{FromUserID: &fromID, ToUserID: &toID, AmountCents: amount, Type: TransactionTypeTransfer, Status: TransactionStatusPending}
+	if fromID == toID && fromCur == toCur {
+		println("❌ Aynı hesaba transfer yapılamaz")
+		return nil, fmt.Errorf("cannot transfer to same account")
+	}
 
-	if err := db.DB.Create(txModel).Error; err != nil {
-		println("❌ Transfer transaction oluşturulamadı:", err.Error())
-		return nil, fmt.Errorf("failed to create transfer transaction: %w", err)
+	txModel := &Transaction{AmountCents: amount, Currency: fromCur, Type: TransactionTypeTransfer, Status: TransactionStatusPending}
+	if err := bindAccounts(txModel, &fromID, &toID, true); err != nil {
+		println("❌ Hesap kullanılamıyor:", err.Error())
+		return nil, err
 	}
 
-	println("📝 Transfer transaction oluşturuldu, ID:", txModel.ID)
+	if fromCur != toCur {
+		quote, err := currency.Default().Quote(amount, fromCur, toCur)
+		if err != nil {
+			println("❌ Döviz kuru alınamadı:", err.Error())
+			return nil, fmt.Errorf("failed to quote %s/%s: %w", fromCur, toCur, err)
+		}
+		txModel.ToCurrency = toCur
+		txModel.ToAmountCents = quote.ToAmountCents
+		txModel.FXRate = quote.MidRate
+		txModel.FXSpreadBps = quote.SpreadBps
+		txModel.FXAppliedRate = quote.AppliedRate
+		println("💱 Kur uygulandı:", quote.AppliedRate, "alıcıya:", quote.ToAmountCents, toCur)
+	}
 
-	// First debit from source account
-	if err := balance.Debit(fromID, amount); err != nil {
-		println("❌ Kaynak hesaptan debit başarısız:", err.Error())
-		txModel.Status = TransactionStatusFailed
-		txModel.FailureCause = err.Error()
-		if saveErr := db.DB.Save(txModel).Error; saveErr != nil {
-			println("⚠️ Failed transfer transaction kaydedilemedi:", saveErr.Error())
+	// Screen before any money moves; blocked and reviewed transfers never
+	// reach the balances
+	decision, err := fraud.Default().Screen(db.DB, fraud.Candidate{
+		Type:        string(TransactionTypeTransfer),
+		FromUserID:  *txModel.FromUserID,
+		ToUserID:    *txModel.ToUserID,
+		Currency:    fromCur,
+		AmountCents: amount,
+	})
+	if err != nil {
+		println("❌ Fraud taraması başarısız:", err.Error())
+		return nil, err
+	}
+	switch decision.Outcome {
+	case fraud.OutcomeBlock:
+		recordFailure(txModel, fraud.ErrBlocked)
+		saveDecision(decision, txModel)
+		return txModel, fraud.ErrBlocked
+	case fraud.OutcomeReview:
+		if err := holdForReview(txModel, decision); err != nil {
+			return nil, err
 		}
+		println("⏸️ Transfer incelemeye alındı, transaction ID:", txModel.ID)
+		return txModel, nil
+	}
+
+	err = execute(txModel, transferApply(txModel))
+	saveDecision(decision, txModel)
+	if err != nil {
 		return txModel, err
 	}
 
-	println("✅ Kaynak hesaptan debit başarılı")
+	println("✅ Transfer işlemi başarıyla tamamlandı, transaction ID:", txModel.ID)
+	return txModel, nil
+}
 
-	// Then credit to destination account
-	if err := balance.Credit(toID, amount); err != nil {
-		println("❌ Hedef hesaba kredi başarısız, rollback yapılıyor:", err.Error())
-		// rollback debit if credit fails
-		if rollbackErr := balance.Credit(fromID, amount); rollbackErr != nil {
-			println("❌ Rollback başarısız:", rollbackErr.Error())
-		} else {
-			println("✅ Rollback başarılı")
+// transferApply returns the unit of work that settles a transfer: the money
+// moves, the payer's limits are checked and the fee is charged.
+func transferApply(txModel *Transaction) func(dbTx *gorm.DB) error {
+	fromID, toID := *txModel.FromAccountID, *txModel.ToAccountID
+	payerID := *txModel.FromUserID
+	amount, fromCur := txModel.AmountCents, txModel.Currency
+	return func(dbTx *gorm.DB) error {
+		role, err := payerRole(dbTx, payerID)
+		if err != nil {
+			return err
+		}
+		if err := move(dbTx, txModel, &fromID, &toID); err != nil {
+			println("❌ Transfer bakiye hareketi başarısız:", err.Error())
+			return err
+		}
+		println("✅ Transfer bakiye hareketi başarılı")
+		if err := checkLimits(dbTx, txModel, payerID, role); err != nil {
+			return err
+		}
+		if err := chargeFee(dbTx, txModel, role); err != nil {
+			return err
 		}
 
-		txModel.Status = TransactionStatusFailed
-		txModel.FailureCause = err.Error()
-		if saveErr := db.DB.Save(txModel).Error; saveErr != nil {
-			println("⚠️ Failed transfer transaction kaydedilemedi:", saveErr.Error())
+		return audit.LogTx(dbTx, "transaction", fmt.Sprintf("%d", txModel.ID), "transfer", fmt.Sprintf("from=%d to=%d amount=%d %s -> %d %s", fromID, toID, amount, fromCur, txModel.CreditAmountCents(), txModel.CreditCurrency()))
+	}
+}
+
+// move debits account fromID and credits account toID inside dbTx and posts
+// the matching journal entry for txModel. A nil side is the bank's cash
+// account, so a credit is move(nil, &account) and a debit is move(&account, nil). The debit leg
+// uses txModel's Currency/AmountCents and the credit leg its credit side, so
+// FX transactions are posted through the FX position account.
+func move(dbTx *gorm.DB, txModel *Transaction, fromID, toID *uint) error {
+	debitCur, debitAmount := txModel.Currency, txModel.AmountCents
+	creditCur, creditAmount := txModel.CreditCurrency(), txModel.CreditAmountCents()
+
+	// Lock every customer balance up front, in key order, before touching any
+	var keys []balance.Key
+	fromAccount, toAccount := ledger.AccountCash, ledger.AccountCash
+	if fromID != nil {
+		keys = append(keys, balance.Key{AccountID: *fromID, Currency: debitCur})
+		fromAccount = ledger.CustomerAccount(*fromID)
+	}
+	if toID != nil {
+		keys = append(keys, balance.Key{AccountID: *toID, Currency: creditCur})
+		toAccount = ledger.CustomerAccount(*toID)
+	}
+	if _, err := balance.LockBalances(dbTx, keys...); err != nil {
+		return err
+	}
+
+	if fromID != nil {
+		if err := balance.DebitTx(dbTx, *fromID, debitCur, debitAmount, txModel.entry()); err != nil {
+			return err
 		}
-		return txModel, err
 	}
+	if toID != nil {
+		if err := balance.CreditTx(dbTx, *toID, creditCur, creditAmount, txModel.entry()); err != nil {
+			return err
+		}
+	}
+
+	postings := []ledger.Posting{ledger.Debit(fromAccount, debitCur, debitAmount)}
+	if txModel.IsFX() {
+		postings = append(postings,
+			ledger.Credit(ledger.AccountFXPosition, debitCur, debitAmount),
+			ledger.Debit(ledger.AccountFXPosition, creditCur, creditAmount),
+		)
+	}
+	postings = append(postings, ledger.Credit(toAccount, creditCur, creditAmount))
+
+	_, err := ledger.PostTx(dbTx, &txModel.ID, string(txModel.Type), postings...)
+	return err
+}
+
+// checkCurrency rejects currencies the bank does not hold
+func checkCurrency(code string) error {
+	if !currency.Default().IsSupported(code) {
+		return fmt.Errorf("unsupported currency: %s", code)
+	}
+	return nil
+}
 
-	println("✅ Hedef hesaba kredi başarılı")
+// execute creates txModel and runs apply in a single database transaction,
+// marking txModel completed and queueing its transaction.completed event on
+// success. If anything fails the whole unit is
+// rolled back and a separate failed transaction row is recorded instead.
+func execute(txModel *Transaction, apply func(dbTx *gorm.DB) error) error {
+	err := db.DB.Transaction(func(dbTx *gorm.DB) error {
+		if err := dbTx.Create(txModel).Error; err != nil {
+			println("❌ Transaction oluşturulamadı:", err.Error())
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+		println("📝 Transaction oluşturuldu, ID:", txModel.ID)
+
+		if err := apply(dbTx); err != nil {
+			return err
+		}
 
-	txModel.Status = TransactionStatusCompleted
-	if err := db.DB.Save(txModel).Error; err != nil {
-		println("❌ Transfer transaction güncellenemedi:", err.Error())
-		return txModel, fmt.Errorf("failed to update transfer transaction: %w", err)
+		if err := txModel.MarkAsCompleted(); err != nil {
+			return err
+		}
+		if err := dbTx.Save(txModel).Error; err != nil {
+			println("❌ Transaction güncellenemedi:", err.Error())
+			return fmt.Errorf("failed to update transaction: %w", err)
+		}
+		return emitTx(dbTx, txModel, EventCompleted)
+	})
+	if err != nil {
+		recordFailure(txModel, err)
 	}
+	return err
+}
 
-	audit.Log("transaction", fmt.Sprintf("%d", txModel.ID), "transfer", fmt.Sprintf("from=%d to=%d amount=%d", fromID, toID, amount))
-	println("✅ Transfer işlemi başarıyla tamamlandı, transaction ID:", txModel.ID)
-	return txModel, nil
+// recordFailure persists a failed transaction row after its unit of work was
+// rolled back, so the attempt stays visible in history, and queues its
+// transaction.failed event.
+func recordFailure(txModel *Transaction, cause error) {
+	txModel.ID = 0
+	txModel.Status = TransactionStatusFailed
+	txModel.FailureCause = cause.Error()
+	txModel.FeeCents = 0
+	txModel.FeeTransaction = nil
+	err := db.DB.Transaction(func(dbTx *gorm.DB) error {
+		if err := dbTx.Create(txModel).Error; err != nil {
+			return err
+		}
+		return emitTx(dbTx, txModel, EventFailed)
+	})
+	if err != nil {
+		println("⚠️ Failed transaction kaydedilemedi:", err.Error())
+	}
 }
@@ -1,6 +1,7 @@
 package transaction
 
 import (
+	"bankapi/internal/balance"
 	"fmt"
 	"time"
 )
@@ -12,49 +13,130 @@ const (
 	TransactionTypeCredit   TransactionType = "credit"
 	TransactionTypeDebit    TransactionType = "debit"
 	TransactionTypeTransfer TransactionType = "transfer"
+	TransactionTypeReversal TransactionType = "reversal"
+	TransactionTypeRefund   TransactionType = "refund"
+	TransactionTypeFee      TransactionType = "fee"
+	TransactionTypeInterest TransactionType = "interest"
+	// TransactionTypeWithholdingTax is the tax withheld from an interest credit
+	TransactionTypeWithholdingTax TransactionType = "withholding_tax"
+	// TransactionTypeOverdraftInterest is interest charged on a negative balance
+	TransactionTypeOverdraftInterest TransactionType = "overdraft_interest"
 
-	TransactionStatusPending   TransactionStatus = "pending"
-	TransactionStatusCompleted TransactionStatus = "completed"
-	TransactionStatusFailed    TransactionStatus = "failed"
+	TransactionStatusPending           TransactionStatus = "pending"
+	TransactionStatusPendingReview     TransactionStatus = "pending_review"
+	TransactionStatusRejected          TransactionStatus = "rejected"
+	TransactionStatusCompleted         TransactionStatus = "completed"
+	TransactionStatusFailed            TransactionStatus = "failed"
+	TransactionStatusReversed          TransactionStatus = "reversed"
+	TransactionStatusPartiallyRefunded TransactionStatus = "partially_refunded"
+	TransactionStatusRefunded          TransactionStatus = "refunded"
+	// TransactionStatusCancelled is a scheduled transfer cancelled before it ran
+	TransactionStatusCancelled TransactionStatus = "cancelled"
 )
 
 type Transaction struct {
-	ID           uint              `json:"id" gorm:"primaryKey"`
-	FromUserID   *uint             `json:"from_user_id" gorm:"index"`
-	ToUserID     *uint             `json:"to_user_id" gorm:"index"`
-	AmountCents  int64             `json:"amount_cents" gorm:"not null;check:amount_cents>0"`
-	Type         TransactionType   `json:"type" gorm:"size:20;not null"`
-	Status       TransactionStatus `json:"status" gorm:"size:20;not null;index"`
-	FailureCause string            `json:"failure_cause" gorm:"size:255"`
-	CreatedAt    time.Time         `json:"created_at"`
-	UpdatedAt    time.Time         `json:"updated_at"`
+	ID                    uint              `json:"id" gorm:"primaryKey"`
+	FromAccountID         *uint             `json:"from_account_id" gorm:"index"`
+	ToAccountID           *uint             `json:"to_account_id" gorm:"index"`
+	FromUserID            *uint             `json:"from_user_id" gorm:"index"` // owner of FromAccountID
+	ToUserID              *uint             `json:"to_user_id" gorm:"index"`   // owner of ToAccountID
+	AmountCents           int64             `json:"amount_cents" gorm:"not null;check:amount_cents>0"`
+	Currency              string            `json:"currency" gorm:"size:3;not null;default:TRY"`
+	ToCurrency            string            `json:"to_currency,omitempty" gorm:"size:3"`
+	ToAmountCents         int64             `json:"to_amount_cents,omitempty"`
+	FXRate                float64           `json:"fx_rate,omitempty"`
+	FXSpreadBps           int               `json:"fx_spread_bps,omitempty"`
+	FXAppliedRate         float64           `json:"fx_applied_rate,omitempty"`
+	Type                  TransactionType   `json:"type" gorm:"size:20;not null"`
+	Status                TransactionStatus `json:"status" gorm:"size:20;not null;index"`
+	FailureCause          string            `json:"failure_cause" gorm:"size:255"`
+	OriginalTransactionID *uint             `json:"original_transaction_id,omitempty" gorm:"index"`
+	RefundedCents         int64             `json:"refunded_cents" gorm:"not null;default:0"`
+	HoldID                *uint             `json:"hold_id,omitempty" gorm:"index"`
+	ParentTransactionID   *uint             `json:"parent_transaction_id,omitempty" gorm:"index"`
+	FeeCents              int64             `json:"fee_cents" gorm:"not null;default:0"`
+	FeeTransaction        *Transaction      `json:"fee_transaction,omitempty" gorm:"-"`
+	ExecuteAt             *time.Time        `json:"execute_at,omitempty" gorm:"index"` // value date of a scheduled transfer
+	CreatedAt             time.Time         `json:"created_at" gorm:"index"`
+	UpdatedAt             time.Time         `json:"updated_at"`
 }
 
 type CreateCreditRequest struct {
-	UserID      uint  `json:"user_id" binding:"required"`
-	AmountCents int64 `json:"amount_cents" binding:"required,gt=0"`
+	AccountID   uint   `json:"account_id" binding:"required"`
+	AmountCents int64  `json:"amount_cents" binding:"required,gt=0"`
+	Currency    string `json:"currency" binding:"omitempty,len=3"`
 }
 
 type CreateDebitRequest struct {
-	UserID      uint  `json:"user_id" binding:"required"`
-	AmountCents int64 `json:"amount_cents" binding:"required,gt=0"`
+	AccountID   uint   `json:"account_id" binding:"required"`
+	AmountCents int64  `json:"amount_cents" binding:"required,gt=0"`
+	Currency    string `json:"currency" binding:"omitempty,len=3"`
 }
 
+// CreateTransferRequest moves AmountCents of Currency from the sender. When
+// ToCurrency differs the receiver is credited the converted amount. The
+// receiver is given either by account ID or by IBAN. With ExecuteAt the
+// transfer is scheduled to run at that time instead of right away.
 type CreateTransferRequest struct {
-	FromUserID  uint  `json:"from_user_id" binding:"required"`
-	ToUserID    uint  `json:"to_user_id" binding:"required,nefield=FromUserID"`
-	AmountCents int64 `json:"amount_cents" binding:"required,gt=0"`
+	FromAccountID uint       `json:"from_account_id" binding:"required"`
+	ToAccountID   uint       `json:"to_account_id" binding:"required_without=ToIBAN"`
+	ToIBAN        string     `json:"to_iban" binding:"max=42"`
+	AmountCents   int64      `json:"amount_cents" binding:"required,gt=0"`
+	Currency      string     `json:"currency" binding:"omitempty,len=3"`
+	ToCurrency    string     `json:"to_currency" binding:"omitempty,len=3"`
+	ExecuteAt     *time.Time `json:"execute_at"`
+}
+
+type PlaceHoldRequest struct {
+	AccountID        uint   `json:"account_id" binding:"required"`
+	AmountCents      int64  `json:"amount_cents" binding:"required,gt=0"`
+	Currency         string `json:"currency" binding:"omitempty,len=3"`
+	Reference        string `json:"reference" binding:"max=100"`
+	ExpiresInSeconds int64  `json:"expires_in_seconds" binding:"gte=0"`
+}
+
+// CaptureHoldRequest captures AmountCents of the hold, or all of it when zero
+type CaptureHoldRequest struct {
+	AmountCents int64 `json:"amount_cents" binding:"gte=0"`
+}
+
+type ReviewDecisionRequest struct {
+	Note string `json:"note" binding:"max=255"`
+}
+
+type ReverseRequest struct {
+	Reason string `json:"reason" binding:"max=200"`
+}
+
+type RefundRequest struct {
+	AmountCents int64  `json:"amount_cents" binding:"required,gt=0"`
+	Reason      string `json:"reason" binding:"max=200"`
 }
 
 // State management methods
 func (t *Transaction) CanTransitionTo(newStatus TransactionStatus) bool {
 	switch t.Status {
 	case TransactionStatusPending:
-		return newStatus == TransactionStatusCompleted || newStatus == TransactionStatusFailed
+		// A scheduled transfer can also be cancelled before it runs or be
+		// held by fraud screening when it does
+		return newStatus == TransactionStatusCompleted ||
+			newStatus == TransactionStatusFailed ||
+			newStatus == TransactionStatusCancelled ||
+			newStatus == TransactionStatusPendingReview
+	case TransactionStatusPendingReview:
+		// Held by fraud screening until an admin approves or rejects it
+		return newStatus == TransactionStatusCompleted ||
+			newStatus == TransactionStatusFailed ||
+			newStatus == TransactionStatusRejected
 	case TransactionStatusCompleted:
-		return false // Can't change completed transactions
-	case TransactionStatusFailed:
-		return false // Can't change failed transactions
+		// Completed transactions can only be undone by a linked reversal/refund
+		return newStatus == TransactionStatusReversed ||
+			newStatus == TransactionStatusPartiallyRefunded ||
+			newStatus == TransactionStatusRefunded
+	case TransactionStatusPartiallyRefunded:
+		return newStatus == TransactionStatusPartiallyRefunded || newStatus == TransactionStatusRefunded
+	case TransactionStatusFailed, TransactionStatusReversed, TransactionStatusRefunded, TransactionStatusRejected, TransactionStatusCancelled:
+		return false // Final states
 	default:
 		return false
 	}
@@ -81,17 +163,49 @@ func (t *Transaction) MarkAsFailed(cause string) error {
 	return nil
 }
 
+// IsCompensatable reports whether the transaction type can be reversed or refunded
+func (t *Transaction) IsCompensatable() bool {
+	return t.Type == TransactionTypeCredit || t.Type == TransactionTypeDebit || t.Type == TransactionTypeTransfer
+}
+
 func (t *Transaction) CanRollback() bool {
-	return t.Status == TransactionStatusCompleted && t.Type != TransactionTypeTransfer
+	return t.Status == TransactionStatusCompleted && t.IsCompensatable() && t.RefundedCents == 0
 }
 
+// Rollback marks the transaction as fully reversed. The caller is responsible
+// for moving the money back with a linked reversal transaction.
 func (t *Transaction) Rollback() error {
 	if !t.CanRollback() {
 		return fmt.Errorf("transaction cannot be rolled back")
 	}
-	t.Status = TransactionStatusFailed
-	t.FailureCause = "Rolled back by user"
-	t.UpdatedAt = time.Now()
+	return t.TransitionTo(TransactionStatusReversed)
+}
+
+// RefundableCents returns the amount that has not been refunded yet
+func (t *Transaction) RefundableCents() int64 {
+	return t.AmountCents - t.RefundedCents
+}
+
+func (t *Transaction) CanRefund(amount int64) bool {
+	if !t.IsCompensatable() || amount <= 0 || amount > t.RefundableCents() {
+		return false
+	}
+	return t.Status == TransactionStatusCompleted || t.Status == TransactionStatusPartiallyRefunded
+}
+
+// Refund records a (partial) refund and moves the status accordingly
+func (t *Transaction) Refund(amount int64) error {
+	if !t.CanRefund(amount) {
+		return fmt.Errorf("transaction cannot be refunded by %d", amount)
+	}
+	next := TransactionStatusPartiallyRefunded
+	if t.RefundedCents+amount == t.AmountCents {
+		next = TransactionStatusRefunded
+	}
+	if err := t.TransitionTo(next); err != nil {
+		return err
+	}
+	t.RefundedCents += amount
 	return nil
 }
 
@@ -102,18 +216,19 @@ func (t *Transaction) Validate() error {
 	}
 
 	if t.Type == TransactionTypeTransfer {
-		if t.FromUserID == nil || t.ToUserID == nil {
-			return fmt.Errorf("transfer transactions require both from and to user IDs")
+		if t.FromAccountID == nil || t.ToAccountID == nil {
+			return fmt.Errorf("transfer transactions require both from and to account IDs")
 		}
-		if *t.FromUserID == *t.ToUserID {
-			return fmt.Errorf("cannot transfer to same user")
+		if *t.FromAccountID == *t.ToAccountID && !t.IsFX() {
+			return fmt.Errorf("cannot transfer to same account")
 		}
 	}
 
-	if t.Type == TransactionTypeCredit || t.Type == TransactionTypeDebit {
-		if t.FromUserID != nil || t.ToUserID != nil {
-			return fmt.Errorf("credit/debit transactions should not have from/to user IDs")
-		}
+	if t.Type == TransactionTypeCredit && (t.ToAccountID == nil || t.FromAccountID != nil) {
+		return fmt.Errorf("credit transactions require only a to account ID")
+	}
+	if t.Type == TransactionTypeDebit && (t.FromAccountID == nil || t.ToAccountID != nil) {
+		return fmt.Errorf("debit transactions require only a from account ID")
 	}
 
 	return nil
@@ -132,6 +247,42 @@ func (t *Transaction) IsDebit() bool {
 	return t.Type == TransactionTypeDebit
 }
 
+// IsFX reports whether the two legs settle in different currencies
+func (t *Transaction) IsFX() bool {
+	return t.ToCurrency != "" && t.ToCurrency != t.Currency
+}
+
+// CreditCurrency is the currency the receiving side is credited in
+func (t *Transaction) CreditCurrency() string {
+	if t.IsFX() {
+		return t.ToCurrency
+	}
+	return t.Currency
+}
+
+// CreditAmountCents is the amount the receiving side is credited
+func (t *Transaction) CreditAmountCents() int64 {
+	if t.IsFX() {
+		return t.ToAmountCents
+	}
+	return t.AmountCents
+}
+
+// entry links a balance change to the transaction
+func (t *Transaction) entry() balance.Entry {
+	return balance.Entry{TransactionID: &t.ID, Type: string(t.Type)}
+}
+
+// IsScheduled reports whether the transaction waits for its value date
+func (t *Transaction) IsScheduled() bool {
+	return t.Status == TransactionStatusPending && t.ExecuteAt != nil
+}
+
+// Involves reports whether the user is the sender or the receiver
+func (t *Transaction) Involves(userID uint) bool {
+	return (t.FromUserID != nil && *t.FromUserID == userID) || (t.ToUserID != nil && *t.ToUserID == userID)
+}
+
 func (t *Transaction) GetAmountInTL() float64 {
 	return float64(t.AmountCents) / 100.0
 }
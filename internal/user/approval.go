@@ -0,0 +1,51 @@
+package user
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKey is where the auth middleware stores the authenticated User
+const ContextKey = "currentUser"
+
+// Actions parked for a second admin
+const (
+	ActionUpdate = "user.update"
+	ActionDelete = "user.delete"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserExists   = errors.New("username or email already exists")
+)
+
+// ApprovalGate parks a sensitive change until a second admin approves it and
+// returns the pending request. It is installed by the approval package, which
+// this package cannot import directly; when nil changes apply immediately.
+type ApprovalGate func(action, entityID string, payload interface{}, requestedBy uint, summary string) (interface{}, error)
+
+var approvalGate ApprovalGate
+
+func SetApprovalGate(gate ApprovalGate) {
+	approvalGate = gate
+}
+
+type UpdateUserPayload struct {
+	UserID uint `json:"user_id"`
+	UpdateUserRequest
+}
+
+type DeleteUserPayload struct {
+	UserID uint `json:"user_id"`
+}
+
+// Current returns the authenticated user of the request
+func Current(c *gin.Context) (User, bool) {
+	val, exists := c.Get(ContextKey)
+	if !exists {
+		return User{}, false
+	}
+	u, ok := val.(User)
+	return u, ok
+}
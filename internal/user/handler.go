@@ -2,6 +2,8 @@ package user
 
 import (
 	"bankapi/internal/db"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -101,6 +103,7 @@ type UpdateUserRequest struct {
 }
 
 // PUT /users/:id → Güncelle
+// Rol değişiklikleri ikinci bir admin onayına kadar bekletilir.
 func UpdateUser(c *gin.Context) {
 	id := c.Param("id")
 	println("✏️ Kullanıcı güncelleniyor, ID:", id)
@@ -121,6 +124,48 @@ func UpdateUser(c *gin.Context) {
 
 	println("✅ Güncelleme verisi alındı")
 
+	if req.Role != nil && *req.Role != u.Role {
+		if err := (User{Role: *req.Role}).ValidateRole(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Geçersiz rol", "message": err.Error()})
+			return
+		}
+		requester, ok := Current(c)
+		if !ok || !requester.IsAdmin() {
+			println("❌ Rol değişikliği için admin yetkisi gerekli")
+			c.JSON(http.StatusForbidden, gin.H{"error": "Erişim reddedildi", "message": "Rol değişikliği yalnızca admin tarafından talep edilebilir"})
+			return
+		}
+		if approvalGate != nil {
+			pending, err := approvalGate(ActionUpdate, id, UpdateUserPayload{UserID: u.ID, UpdateUserRequest: req}, requester.ID,
+				fmt.Sprintf("role %s -> %s for %s", u.Role, *req.Role, u.Username))
+			if err != nil {
+				println("❌ Onay talebi oluşturulamadı:", err.Error())
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Onay talebi oluşturulamadı", "message": "Teknik bir hata oluştu"})
+				return
+			}
+			println("⏸️ Rol değişikliği onaya gönderildi")
+			c.JSON(http.StatusAccepted, gin.H{"message": "Rol değişikliği ikinci bir admin onayı bekliyor", "approval": pending})
+			return
+		}
+	}
+
+	updated, err := ApplyUpdate(u.ID, req)
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	println("✅ Kullanıcı başarıyla güncellendi")
+	c.JSON(http.StatusOK, updated.ToResponse())
+}
+
+// ApplyUpdate writes the requested changes to the user
+func ApplyUpdate(id uint, req UpdateUserRequest) (User, error) {
+	var u User
+	if err := db.DB.First(&u, id).Error; err != nil {
+		return User{}, ErrUserNotFound
+	}
+
 	if req.Username != nil {
 		println("📝 Kullanıcı adı güncelleniyor:", *req.Username)
 		u.Username = *req.Username
@@ -137,26 +182,48 @@ func UpdateUser(c *gin.Context) {
 	if err := db.DB.Save(&u).Error; err != nil {
 		if isUniqueViolation(err) {
 			println("❌ Kullanıcı adı veya e-posta zaten kayıtlı")
-			c.JSON(http.StatusConflict, gin.H{"error": "Kullanıcı zaten mevcut", "message": "Bu kullanıcı adı veya e-posta zaten kayıtlı"})
-			return
+			return User{}, ErrUserExists
 		}
 		println("❌ Kullanıcı güncellenemedi:", err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Güncellenemedi", "message": "Teknik bir hata oluştu"})
-		return
+		return User{}, fmt.Errorf("failed to update user: %w", err)
 	}
-
-	println("✅ Kullanıcı başarıyla güncellendi")
-	c.JSON(http.StatusOK, u.ToResponse())
+	return u, nil
 }
 
 // DELETE /users/:id → Sil
+// Silme işlemi ikinci bir admin onayına kadar bekletilir.
 func DeleteUser(c *gin.Context) {
 	id := c.Param("id")
 	println("🗑️ Kullanıcı siliniyor, ID:", id)
 
-	if err := db.DB.Delete(&User{}, id).Error; err != nil {
-		println("❌ Kullanıcı silinemedi:", err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Silinemedi", "message": "Teknik bir hata oluştu"})
+	var u User
+	if err := db.DB.First(&u, id).Error; err != nil {
+		println("❌ Silinecek kullanıcı bulunamadı:", err.Error())
+		c.JSON(http.StatusNotFound, gin.H{"error": "Kullanıcı bulunamadı", "message": "Belirtilen ID'ye sahip kullanıcı bulunamadı"})
+		return
+	}
+
+	requester, ok := Current(c)
+	if !ok || !requester.IsAdmin() {
+		println("❌ Silme için admin yetkisi gerekli")
+		c.JSON(http.StatusForbidden, gin.H{"error": "Erişim reddedildi", "message": "Kullanıcı silme yalnızca admin tarafından talep edilebilir"})
+		return
+	}
+
+	if approvalGate != nil {
+		pending, err := approvalGate(ActionDelete, id, DeleteUserPayload{UserID: u.ID}, requester.ID, fmt.Sprintf("delete %s", u.Username))
+		if err != nil {
+			println("❌ Onay talebi oluşturulamadı:", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Onay talebi oluşturulamadı", "message": "Teknik bir hata oluştu"})
+			return
+		}
+		println("⏸️ Kullanıcı silme onaya gönderildi")
+		c.JSON(http.StatusAccepted, gin.H{"message": "Kullanıcı silme ikinci bir admin onayı bekliyor", "approval": pending})
+		return
+	}
+
+	if err := Delete(u.ID); err != nil {
+		respondUserError(c, err)
 		return
 	}
 
@@ -164,6 +231,30 @@ func DeleteUser(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// Delete removes the user
+func Delete(id uint) error {
+	res := db.DB.Delete(&User{}, id)
+	if res.Error != nil {
+		println("❌ Kullanıcı silinemedi:", res.Error.Error())
+		return fmt.Errorf("failed to delete user: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func respondUserError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Kullanıcı bulunamadı", "message": "Belirtilen ID'ye sahip kullanıcı bulunamadı"})
+	case errors.Is(err, ErrUserExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "Kullanıcı zaten mevcut", "message": "Bu kullanıcı adı veya e-posta zaten kayıtlı"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "İşlem başarısız", "message": "Teknik bir hata oluştu"})
+	}
+}
+
 // naive unique detection without pg driver types for simplicity
 func isUniqueViolation(err error) bool {
 	if err == nil {
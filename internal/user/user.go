@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type User struct {
@@ -99,3 +101,22 @@ func (u User) IsModerator() bool {
 func (u User) CanModifyUser(targetUser User) bool {
 	return u.IsAdmin() || u.ID == targetUser.ID
 }
+
+// CreateHook runs in the database transaction of every user insert. It is
+// installed by the account package, which opens the user's first account;
+// this package cannot import it directly.
+type CreateHook func(tx *gorm.DB, u *User) error
+
+var createHook CreateHook
+
+func SetCreateHook(hook CreateHook) {
+	createHook = hook
+}
+
+// AfterCreate is the gorm hook that runs the create hook
+func (u *User) AfterCreate(tx *gorm.DB) error {
+	if createHook == nil {
+		return nil
+	}
+	return createHook(tx, u)
+}
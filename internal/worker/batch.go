@@ -9,6 +9,10 @@ import (
 	"time"
 )
 
+// BatchRetention is how long a finished batch stays available to
+// GetBatchStatus
+const BatchRetention = time.Hour
+
 // BatchProcessor handles batch transaction processing
 type BatchProcessor struct {
 	workerPool   *WorkerPool
@@ -16,16 +20,19 @@ type BatchProcessor struct {
 	batchTimeout time.Duration
 	stats        *TransactionStats
 	mu           sync.RWMutex
+	batches      map[string]*BatchTransaction
 }
 
 // BatchTransaction represents a batch of transactions
 type BatchTransaction struct {
 	ID           string
 	Transactions []transaction.Transaction
+	Results      []*BatchResult // Results[i] belongs to Transactions[i]; set under the processor's lock
 	Status       BatchStatus
 	CreatedAt    time.Time
 	CompletedAt  *time.Time
 	Error        string
+	Done         chan struct{} // closed once every transaction has a result
 }
 
 type BatchStatus string
@@ -44,6 +51,7 @@ func NewBatchProcessor(workerPool *WorkerPool, batchSize int, batchTimeout time.
 		batchSize:    batchSize,
 		batchTimeout: batchTimeout,
 		stats:        &TransactionStats{},
+		batches:      make(map[string]*BatchTransaction),
 	}
 }
 
@@ -60,10 +68,17 @@ func (bp *BatchProcessor) ProcessBatch(ctx context.Context, transactions []trans
 	batch := &BatchTransaction{
 		ID:           generateBatchID(),
 		Transactions: transactions,
+		Results:      make([]*BatchResult, len(transactions)),
 		Status:       BatchStatusPending,
 		CreatedAt:    time.Now(),
+		Done:         make(chan struct{}),
 	}
 
+	bp.mu.Lock()
+	bp.evictFinished(batch.CreatedAt)
+	bp.batches[batch.ID] = batch
+	bp.mu.Unlock()
+
 	// Start processing in background
 	go bp.processBatchAsync(ctx, batch)
 
@@ -76,46 +91,45 @@ func (bp *BatchProcessor) processBatchAsync(ctx context.Context, batch *BatchTra
 	batch.Status = BatchStatusProcessing
 	bp.mu.Unlock()
 
-	// Create context with timeout
+	// The timeout bounds how long lines wait to be queued. A line that is
+	// queued always runs to its result, so a line is only reported failed
+	// when it was not run.
 	batchCtx, cancel := context.WithTimeout(ctx, bp.batchTimeout)
 	defer cancel()
 
-	// Process transactions concurrently
+	// Process transactions concurrently. Each goroutine fills its own slot in
+	// batch.Results under bp.mu so GetBatchStatus can copy them meanwhile.
 	var wg sync.WaitGroup
-	results := make(chan *BatchResult, len(batch.Transactions))
-	errors := make(chan error, len(batch.Transactions))
-
-	// Submit transactions to worker pool
-	for _, txn := range batch.Transactions {
+	for i, txn := range batch.Transactions {
 		wg.Add(1)
-		go func(t transaction.Transaction) {
+		go func(i int, t transaction.Transaction) {
 			defer wg.Done()
 
 			// Submit to worker pool
 			result, err := bp.workerPool.SubmitTransaction(batchCtx, t)
 			if err != nil {
-				errors <- err
+				logger.Error("Batch transaction failed", err, map[string]interface{}{
+					"batch_id": batch.ID,
+				})
+				bp.setResult(batch, i, &BatchResult{Transaction: t, Success: false, Error: err.Error(), ProcessedAt: time.Now()})
 				return
 			}
 
-			results <- result
-		}(txn)
+			br := &BatchResult{Transaction: t, Success: result.Success, Error: result.Error, ProcessedAt: result.ProcessedAt}
+			if result.Transaction != nil {
+				br.Transaction = *result.Transaction
+			}
+			bp.setResult(batch, i, br)
+		}(i, txn)
 	}
-
-	// Wait for all transactions to complete
-	go func() {
-		wg.Wait()
-		close(results)
-		close(errors)
-	}()
+	wg.Wait()
 
 	// Collect results
 	var successfulCount int
 	var failedCount int
 	var totalAmount int64
 
-	// Process results
-	for result := range results {
+	for _, result := range batch.Results {
 		if result != nil && result.Success {
 			successfulCount++
 			totalAmount += result.Transaction.AmountCents
@@ -124,16 +138,6 @@ func (bp *BatchProcessor) processBatchAsync(ctx context.Context, batch *BatchTra
 		}
 	}
 
-	// Process errors
-	for err := range errors {
-		if err != nil {
-			failedCount++
-			logger.Error("Batch transaction failed", err, map[string]interface{}{
-				"batch_id": batch.ID,
-			})
-		}
-	}
-
 	// Update batch status
 	bp.mu.Lock()
 	if failedCount == 0 {
@@ -148,6 +152,7 @@ func (bp *BatchProcessor) processBatchAsync(ctx context.Context, batch *BatchTra
 	batch.CompletedAt = &time.Time{}
 	*batch.CompletedAt = time.Now()
 	bp.mu.Unlock()
+	close(batch.Done)
 
 	// Update statistics
 	bp.stats.IncrementTotal()
@@ -169,11 +174,41 @@ func (bp *BatchProcessor) processBatchAsync(ctx context.Context, batch *BatchTra
 	})
 }
 
-// GetBatchStatus returns the current status of a batch
+// setResult records the result of batch.Transactions[i]
+func (bp *BatchProcessor) setResult(batch *BatchTransaction, i int, result *BatchResult) {
+	bp.mu.Lock()
+	batch.Results[i] = result
+	bp.mu.Unlock()
+}
+
+// evictFinished drops batches that finished more than BatchRetention before
+// now. The caller holds bp.mu.
+func (bp *BatchProcessor) evictFinished(now time.Time) {
+	for id, batch := range bp.batches {
+		if batch.CompletedAt != nil && now.Sub(*batch.CompletedAt) > BatchRetention {
+			delete(bp.batches, id)
+		}
+	}
+}
+
+// GetBatchStatus returns a snapshot of a batch that is safe to read while
+// the batch is still being processed. Batches are kept in memory for
+// BatchRetention after they finish; callers that need them for longer or
+// across restarts persist the results themselves once Done is closed.
 func (bp *BatchProcessor) GetBatchStatus(batchID string) (*BatchTransaction, error) {
-	// This is a simplified implementation
-	// In a real system, you'd store batches in a database
-	return nil, fmt.Errorf("batch status tracking not implemented")
+	bp.mu.RLock()
+	defer bp.mu.RUnlock()
+	batch, ok := bp.batches[batchID]
+	if !ok || (batch.CompletedAt != nil && time.Since(*batch.CompletedAt) > BatchRetention) {
+		return nil, fmt.Errorf("batch not found: %s", batchID)
+	}
+	snapshot := *batch
+	snapshot.Results = append([]*BatchResult(nil), batch.Results...)
+	if batch.CompletedAt != nil {
+		completedAt := *batch.CompletedAt
+		snapshot.CompletedAt = &completedAt
+	}
+	return &snapshot, nil
 }
 
 // GetStats returns batch processing statistics
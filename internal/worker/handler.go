@@ -10,8 +10,8 @@ func RegisterRoutes(router *gin.Engine, p *Processor) {
 	{
 		r.POST("/credit", func(c *gin.Context) {
 			var req struct {
-				UserID uint  `json:"user_id"`
-				Amount int64 `json:"amount_cents"`
+				AccountID uint  `json:"account_id"`
+				Amount    int64 `json:"amount_cents"`
 			}
 			if err := c.ShouldBindJSON(&req); err != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "Geçersiz veri"})
@@ -19,15 +19,15 @@ func RegisterRoutes(router *gin.Engine, p *Processor) {
 			}
 			var j Job
 			j.Kind = "credit"
-			j.Credit.UserID = req.UserID
+			j.Credit.AccountID = req.AccountID
 			j.Credit.Amount = req.Amount
 			p.Enqueue(j)
 			c.Status(http.StatusAccepted)
 		})
 		r.POST("/debit", func(c *gin.Context) {
 			var req struct {
-				UserID uint  `json:"user_id"`
-				Amount int64 `json:"amount_cents"`
+				AccountID uint  `json:"account_id"`
+				Amount    int64 `json:"amount_cents"`
 			}
 			if err := c.ShouldBindJSON(&req); err != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "Geçersiz veri"})
@@ -35,15 +35,15 @@ func RegisterRoutes(router *gin.Engine, p *Processor) {
 			}
 			var j Job
 			j.Kind = "debit"
-			j.Debit.UserID = req.UserID
+			j.Debit.AccountID = req.AccountID
 			j.Debit.Amount = req.Amount
 			p.Enqueue(j)
 			c.Status(http.StatusAccepted)
 		})
 		r.POST("/transfer", func(c *gin.Context) {
 			var req struct {
-				FromID uint  `json:"from_user_id"`
-				ToID   uint  `json:"to_user_id"`
+				FromID uint  `json:"from_account_id"`
+				ToID   uint  `json:"to_account_id"`
 				Amount int64 `json:"amount_cents"`
 			}
 			if err := c.ShouldBindJSON(&req); err != nil {
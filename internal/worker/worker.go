@@ -1,8 +1,10 @@
 package worker
 
 import (
+	"bankapi/internal/currency"
 	"bankapi/internal/transaction"
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -12,12 +14,12 @@ import (
 type Job struct {
 	Kind   string
 	Credit struct {
-		UserID uint
-		Amount int64
+		AccountID uint
+		Amount    int64
 	}
 	Debit struct {
-		UserID uint
-		Amount int64
+		AccountID uint
+		Amount    int64
 	}
 	Transfer struct {
 		FromID uint
@@ -41,8 +43,13 @@ type WorkerPool struct {
 	ctx      context.Context
 	cancel   context.CancelFunc
 	wg       sync.WaitGroup
+	mu       sync.RWMutex // held for reading while a job is sent to jobQueue
+	stopped  bool
 }
 
+// ErrPoolStopped is returned for jobs submitted after Stop
+var ErrPoolStopped = errors.New("worker pool is stopped")
+
 // TransactionJob represents a transaction job
 type TransactionJob struct {
 	ID          string
@@ -53,6 +60,7 @@ type TransactionJob struct {
 // TransactionResult represents the result of processing a transaction
 type TransactionResult struct {
 	ID          string
+	Transaction *transaction.Transaction
 	Success     bool
 	Error       string
 	ProcessedAt time.Time
@@ -77,14 +85,14 @@ func (p *Processor) ProcessForever() {
 				var err error
 				switch j.Kind {
 				case "credit":
-					println("💳 Kredi işlemi işleniyor, kullanıcı:", j.Credit.UserID, "miktar:", j.Credit.Amount)
-					_, err = transaction.ApplyCredit(j.Credit.UserID, j.Credit.Amount)
+					println("💳 Kredi işlemi işleniyor, hesap:", j.Credit.AccountID, "miktar:", j.Credit.Amount)
+					_, err = transaction.ApplyCredit(j.Credit.AccountID, currency.DefaultCurrency, j.Credit.Amount)
 				case "debit":
-					println("💸 Debit işlemi işleniyor, kullanıcı:", j.Debit.UserID, "miktar:", j.Debit.Amount)
-					_, err = transaction.ApplyDebit(j.Debit.UserID, j.Debit.Amount)
+					println("💸 Debit işlemi işleniyor, hesap:", j.Debit.AccountID, "miktar:", j.Debit.Amount)
+					_, err = transaction.ApplyDebit(j.Debit.AccountID, currency.DefaultCurrency, j.Debit.Amount)
 				case "transfer":
 					println("🔄 Transfer işlemi işleniyor, from:", j.Transfer.FromID, "to:", j.Transfer.ToID, "miktar:", j.Transfer.Amount)
-					_, err = transaction.ApplyTransfer(j.Transfer.FromID, j.Transfer.ToID, j.Transfer.Amount)
+					_, err = transaction.ApplyTransfer(j.Transfer.FromID, j.Transfer.ToID, currency.DefaultCurrency, currency.DefaultCurrency, j.Transfer.Amount)
 				default:
 					println("❌ Bilinmeyen iş tipi:", j.Kind)
 					err = fmt.Errorf("unknown job kind: %s", j.Kind)
@@ -135,16 +143,28 @@ func (wp *WorkerPool) Start() {
 	println("✅ Worker pool başlatıldı")
 }
 
-// Stop stops the worker pool
+// Stop stops accepting jobs, lets the workers finish every job already
+// queued and then stops them
 func (wp *WorkerPool) Stop() {
 	println("🛑 Worker pool durduruluyor...")
-	wp.cancel()
+	wp.mu.Lock()
+	if wp.stopped {
+		wp.mu.Unlock()
+		return
+	}
+	wp.stopped = true
 	close(wp.jobQueue)
+	wp.mu.Unlock()
+
 	wp.wg.Wait()
+	wp.cancel()
 	println("✅ Worker pool durduruldu")
 }
 
-// SubmitTransaction submits a transaction for processing
+// SubmitTransaction submits a transaction for processing and returns its
+// result. ctx only bounds the wait for room in the queue: once a job is
+// queued a worker will run it, so its result is awaited however long it
+// takes. An error therefore always means the transaction was not run.
 func (wp *WorkerPool) SubmitTransaction(ctx context.Context, txn transaction.Transaction) (*TransactionResult, error) {
 	println("📝 Transaction worker pool'a gönderiliyor, ID:", txn.ID)
 
@@ -154,71 +174,60 @@ func (wp *WorkerPool) SubmitTransaction(ctx context.Context, txn transaction.Tra
 		ResultChan:  make(chan *TransactionResult, 1),
 	}
 
-	select {
-	case wp.jobQueue <- job:
-		println("✅ Transaction başarıyla gönderildi, job ID:", job.ID)
-	case <-ctx.Done():
-		println("❌ Context iptal edildi")
-		return nil, ctx.Err()
-	default:
-		println("❌ Worker pool kuyruğu dolu")
-		return nil, fmt.Errorf("worker pool queue is full")
+	if err := wp.enqueue(ctx, job); err != nil {
+		println("❌ Transaction kuyruğa alınamadı:", err.Error())
+		return nil, err
 	}
+	println("✅ Transaction başarıyla gönderildi, job ID:", job.ID)
 
-	// Wait for result
 	println("⏳ Transaction sonucu bekleniyor...")
+	result := <-job.ResultChan
+	println("✅ Transaction sonucu alındı, başarılı:", result.Success)
+	return result, nil
+}
+
+// enqueue waits for room in the queue rather than dropping the job, so large
+// batches are throttled to the pool's capacity. The read lock keeps Stop
+// from closing the queue during the send.
+func (wp *WorkerPool) enqueue(ctx context.Context, job *TransactionJob) error {
+	wp.mu.RLock()
+	defer wp.mu.RUnlock()
+
+	if wp.stopped {
+		return ErrPoolStopped
+	}
 	select {
-	case result := <-job.ResultChan:
-		println("✅ Transaction sonucu alındı, başarılı:", result.Success)
-		return result, nil
+	case wp.jobQueue <- job:
+		return nil
 	case <-ctx.Done():
-		println("❌ Context timeout, sonuç alınamadı")
-		return nil, ctx.Err()
+		return ctx.Err()
 	}
 }
 
-// worker processes jobs from the queue
+// worker processes jobs from the queue until Stop closes it. Every job it
+// takes gets a result, which SubmitTransaction is waiting for.
 func (wp *WorkerPool) worker(id int) {
 	defer wp.wg.Done()
 
-	for {
-		select {
-		case job, ok := <-wp.jobQueue:
-			if !ok {
-				println("👷 Worker", id, "kuyruk kapandı, durduruluyor")
-				return // Channel closed
-			}
-
-			println("🔧 Worker", id, "transaction işliyor, job ID:", job.ID)
-
-			// Process the transaction
-			result := wp.processTransaction(job)
-
-			// Send result
-			select {
-			case job.ResultChan <- result:
-				println("✅ Worker", id, "sonucu gönderdi")
-			default:
-				println("⚠️ Worker", id, "sonuç kanalı dolu")
-			}
-
-		case <-wp.ctx.Done():
-			println("👷 Worker", id, "context iptal edildi, durduruluyor")
-			return
-		}
+	for job := range wp.jobQueue {
+		println("🔧 Worker", id, "transaction işliyor, job ID:", job.ID)
+		job.ResultChan <- wp.processTransaction(job)
+		println("✅ Worker", id, "sonucu gönderdi")
 	}
+	println("👷 Worker", id, "kuyruk kapandı, durduruluyor")
 }
 
-// processTransaction processes a single transaction
+// processTransaction applies a single transaction through the transaction
+// service. The job's Transaction describes what to do: its type, parties,
+// amount and currencies.
 func (wp *WorkerPool) processTransaction(job *TransactionJob) *TransactionResult {
 	println("🔧 Transaction işleniyor, job ID:", job.ID)
 
 	wp.stats.IncrementTotal()
 	wp.stats.IncrementPending()
 
-	// Simulate transaction processing
-	// In a real system, this would interact with the database
-	success := true
+	applied, err := applyTransaction(job.Transaction)
+	success := err == nil
 	var errMsg string
 
 	// Update statistics
@@ -228,8 +237,8 @@ func (wp *WorkerPool) processTransaction(job *TransactionJob) *TransactionResult
 		println("✅ Transaction başarılı, miktar:", job.Transaction.AmountCents)
 	} else {
 		wp.stats.IncrementFailed()
-		errMsg = "Transaction processing failed"
-		println("❌ Transaction başarısız")
+		errMsg = err.Error()
+		println("❌ Transaction başarısız:", errMsg)
 	}
 
 	wp.stats.DecrementPending()
@@ -237,6 +246,7 @@ func (wp *WorkerPool) processTransaction(job *TransactionJob) *TransactionResult
 
 	result := &TransactionResult{
 		ID:          job.ID,
+		Transaction: applied,
 		Success:     success,
 		Error:       errMsg,
 		ProcessedAt: time.Now(),
@@ -246,6 +256,38 @@ func (wp *WorkerPool) processTransaction(job *TransactionJob) *TransactionResult
 	return result
 }
 
+// applyTransaction dispatches t to the matching transaction service call
+func applyTransaction(t transaction.Transaction) (*transaction.Transaction, error) {
+	cur := t.Currency
+	if cur == "" {
+		cur = currency.DefaultCurrency
+	}
+
+	switch t.Type {
+	case transaction.TransactionTypeCredit:
+		if t.ToAccountID == nil {
+			return nil, fmt.Errorf("credit requires a receiving account")
+		}
+		return transaction.ApplyCredit(*t.ToAccountID, cur, t.AmountCents)
+	case transaction.TransactionTypeDebit:
+		if t.FromAccountID == nil {
+			return nil, fmt.Errorf("debit requires a paying account")
+		}
+		return transaction.ApplyDebit(*t.FromAccountID, cur, t.AmountCents)
+	case transaction.TransactionTypeTransfer:
+		if t.FromAccountID == nil || t.ToAccountID == nil {
+			return nil, fmt.Errorf("transfer requires both accounts")
+		}
+		toCur := t.ToCurrency
+		if toCur == "" {
+			toCur = cur
+		}
+		return transaction.ApplyTransfer(*t.FromAccountID, *t.ToAccountID, cur, toCur, t.AmountCents)
+	default:
+		return nil, fmt.Errorf("unsupported transaction type: %s", t.Type)
+	}
+}
+
 // generateJobID generates a unique job ID
 func generateJobID() string {
 	println("🆔 Job ID oluşturuluyor...")
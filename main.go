@@ -1,28 +1,42 @@
 package main
 
 import (
+	"bankapi/internal/account"
+	"bankapi/internal/approval"
 	"bankapi/internal/audit"
 	"bankapi/internal/auth"
 	"bankapi/internal/balance"
+	"bankapi/internal/bulk"
 	"bankapi/internal/cache"
 	"bankapi/internal/config"
 	"bankapi/internal/currency"
 	"bankapi/internal/db"
 	"bankapi/internal/events"
+	"bankapi/internal/fee"
+	"bankapi/internal/fraud"
+	"bankapi/internal/idempotency"
+	"bankapi/internal/interest"
+	"bankapi/internal/ledger"
+	"bankapi/internal/limits"
 	"bankapi/internal/logger"
 	"bankapi/internal/metrics"
 	"bankapi/internal/middleware"
+	"bankapi/internal/reconciliation"
 	"bankapi/internal/scheduler"
+	"bankapi/internal/statement"
 	"bankapi/internal/telemetry"
 	"bankapi/internal/transaction"
 	"bankapi/internal/user"
+	"bankapi/internal/worker"
 
 	"context"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -97,10 +111,33 @@ func main() {
 		// Her modeli ayrı ayrı migrate et, hata olursa devam et
 		models := []interface{}{
 			&user.User{},
+			&account.Account{},
 			&balance.Balance{},
 			&balance.BalanceHistory{},
+			&balance.Snapshot{},
+			&balance.Hold{},
 			&transaction.Transaction{},
 			&audit.AuditLog{},
+			&ledger.Account{},
+			&ledger.JournalEntry{},
+			&ledger.Posting{},
+			&idempotency.Record{},
+			&fee.Rule{},
+			&limits.UserLimit{},
+			&fraud.Decision{},
+			&fraud.RuleHit{},
+			&approval.Request{},
+			&bulk.Batch{},
+			&bulk.Line{},
+			&interest.Tier{},
+			&interest.Accrual{},
+			&interest.Capitalization{},
+			&reconciliation.Run{},
+			&reconciliation.Discrepancy{},
+			&events.OutboxMessage{},
+			&events.EventRecord{},
+			&events.AggregateSnapshot{},
+			&events.DeadLetter{},
 		}
 
 		for _, model := range models {
@@ -120,6 +157,13 @@ func main() {
 
 		println("✅ Veritabanı migration tamamlandı")
 
+		if err := ledger.SeedSystemAccounts(); err != nil {
+			println("⚠️ Ledger hesapları oluşturulamadı:", err.Error())
+		}
+
+		// Every new user, the seeded admin included, starts with a checking account
+		user.SetCreateHook(account.OpenDefaultTx)
+
 		// Seed admin user if not exists
 		println("👑 Admin kullanıcı kontrol ediliyor...")
 		seedAdminUser()
@@ -134,12 +178,72 @@ func main() {
 		}
 	}()
 
-	// Initialize event bus and scheduler
-	eventBus := events.NewInMemoryEventBus()
+	// Initialize event bus and scheduler. The async bus is closed after the
+	// scheduler stops, so queued events are handled before the database
+	// connection is closed.
+	var eventBus events.EventBus
+	var asyncBus *events.AsyncEventBus
+	if cfg.EventBusMode == "sync" {
+		eventBus = events.NewInMemoryEventBus()
+	} else {
+		opts := events.AsyncOptions{}
+		if n, err := strconv.Atoi(cfg.EventMaxAttempts); err == nil && n > 0 {
+			opts.MaxAttempts = n
+		}
+		asyncBus = events.NewAsyncEventBus(opts)
+		defer asyncBus.Close()
+		eventBus = asyncBus
+	}
 	sched := scheduler.NewScheduler(eventBus)
 	sched.Start()
 	defer sched.Stop()
 
+	if db.DB != nil {
+		// Publish committed domain events from the outbox
+		relay := events.NewOutboxRelay(eventBus)
+		if err := sched.AddJob("outbox-relay", "*/5 * * * * *", func() { _, _ = relay.RelayOnce() }); err != nil {
+			println("⚠️ Outbox iletim işi kaydedilemedi:", err.Error())
+		}
+		if err := sched.AddJob("idempotency-sweep", "0 15 * * * *", func() { _, _ = idempotency.PurgeExpired() }); err != nil {
+			println("⚠️ Idempotency temizlik işi kaydedilemedi:", err.Error())
+		}
+		if err := sched.AddJob("hold-expiry", "0 * * * * *", func() { _, _ = balance.ExpireHolds() }); err != nil {
+			println("⚠️ Provizyon süre işi kaydedilemedi:", err.Error())
+		}
+		if err := sched.AddJob("scheduled-transfers", "30 * * * * *", func() { _, _ = transaction.DispatchDueTransfers() }); err != nil {
+			println("⚠️ Planlı transfer işi kaydedilemedi:", err.Error())
+		}
+		// Close yesterday's balances for point-in-time and series queries
+		if err := sched.AddJob("balance-snapshot", "0 10 0 * * *", func() {
+			_, _ = balance.SnapshotDay(time.Now().AddDate(0, 0, -1))
+		}); err != nil {
+			println("⚠️ Bakiye görüntüsü işi kaydedilemedi:", err.Error())
+		}
+		// Accrue yesterday's interest shortly after midnight and pay the
+		// previous month's accruals on the first of every month
+		if err := sched.AddJob("interest-accrual", "0 5 0 * * *", func() {
+			_, _ = interest.AccrueDay(time.Now().AddDate(0, 0, -1))
+		}); err != nil {
+			println("⚠️ Faiz tahakkuk işi kaydedilemedi:", err.Error())
+		}
+		if err := sched.AddJob("interest-capitalization", "0 30 0 1 * *", func() {
+			_, _ = interest.Capitalize(time.Now())
+		}); err != nil {
+			println("⚠️ Faiz anaparaya ekleme işi kaydedilemedi:", err.Error())
+		}
+		if err := sched.AddJob("reconciliation", "0 0 2 * * *", func() {
+			_, _ = reconciliation.Reconcile(reconciliation.TriggerScheduled)
+		}); err != nil {
+			println("⚠️ Mutabakat işi kaydedilemedi:", err.Error())
+		}
+	}
+
+	// Worker pool for bulk payment files
+	pool := worker.NewWorkerPool(4)
+	pool.Start()
+	defer pool.Stop()
+	batchProcessor := worker.NewBatchProcessor(pool, bulk.MaxLines, 10*time.Minute)
+
 	// Initialize Redis cache (will fail gracefully if Redis is not available)
 	println("🔴 Redis cache başlatılıyor...")
 	redisCache := cache.NewRedisCache(cfg.RedisHost+":"+cfg.RedisPort, cfg.RedisPassword, 0)
@@ -165,14 +269,41 @@ func main() {
 	// Register metrics endpoint
 	metrics.Register(router)
 
+	// Maker-checker threshold for large transfers
+	if threshold, err := strconv.ParseInt(cfg.ApprovalThresholdCents, 10, 64); err == nil {
+		approval.SetTransferThreshold(threshold)
+	}
+	if bps, err := strconv.ParseInt(cfg.InterestWithholdingBps, 10, 64); err == nil && bps >= 0 && bps <= 10000 {
+		interest.SetWithholdingRate(bps)
+	}
+	if bps, err := strconv.ParseInt(cfg.OverdraftRateBps, 10, 64); err == nil && bps >= 0 {
+		interest.SetOverdraftRate(bps)
+	}
+	if n, err := strconv.ParseInt(cfg.AccountSnapshotEvery, 10, 64); err == nil && n >= 0 {
+		balance.SetSnapshotEvery(n)
+	}
+
 	// Register all API routes
 	auth.RegisterAuthRoutes(router)
-	user.RegisterUserRoutes(router)
-	transaction.RegisterRoutes(router)
+	user.RegisterUserRoutes(router, middleware.AuthMiddleware(cfg))
+	account.RegisterRoutes(router, middleware.AuthMiddleware(cfg))
+	transaction.RegisterRoutes(router, middleware.AuthMiddleware(cfg))
 	balance.RegisterRoutes(router)
+	balance.RegisterCreditLimitRoutes(router, middleware.AuthMiddleware(cfg))
+	balance.RegisterProjectionRoutes(router, middleware.AuthMiddleware(cfg))
 	audit.RegisterRoutes(router, middleware.AuthMiddleware(cfg))
 	scheduler.RegisterRoutes(router, middleware.AuthMiddleware(cfg), sched)
+	events.RegisterDeadLetterRoutes(router, middleware.AuthMiddleware(cfg), asyncBus)
 	currency.RegisterRoutes(router, middleware.AuthMiddleware(cfg))
+	ledger.RegisterRoutes(router, middleware.AuthMiddleware(cfg))
+	fee.RegisterRoutes(router, middleware.AuthMiddleware(cfg))
+	limits.RegisterRoutes(router, middleware.AuthMiddleware(cfg))
+	fraud.RegisterRoutes(router, middleware.AuthMiddleware(cfg))
+	approval.RegisterRoutes(router, middleware.AuthMiddleware(cfg))
+	statement.RegisterRoutes(router, middleware.AuthMiddleware(cfg))
+	bulk.RegisterRoutes(router, middleware.AuthMiddleware(cfg), batchProcessor)
+	interest.RegisterRoutes(router, middleware.AuthMiddleware(cfg))
+	reconciliation.RegisterRoutes(router, middleware.AuthMiddleware(cfg))
 
 	// API info endpoint
 	router.GET("/api/v1/info", func(c *gin.Context) {
@@ -182,11 +313,26 @@ func main() {
 			"endpoints": map[string]interface{}{
 				"authentication": "/api/v1/auth/*",
 				"users":          "/api/v1/users/*",
+				"accounts":       "/api/v1/accounts/*",
+				"iban":           "/api/v1/iban/validate",
 				"transactions":   "/api/v1/transactions/*",
 				"balances":       "/api/v1/balances/*",
+				"holds":          "/api/v1/holds/*",
 				"audit":          "/api/v1/audit/*",
 				"scheduler":      "/api/v1/scheduler/*",
 				"currency":       "/api/v1/currency/*",
+				"ledger":         "/api/v1/ledger/*",
+				"fees":           "/api/v1/fees/*",
+				"limits":         "/api/v1/limits/*",
+				"fraud":          "/api/v1/fraud/*",
+				"approvals":      "/api/v1/approvals/*",
+				"statements":     "/api/v1/statements",
+				"bulk_payments":  "/api/v1/bulk/payments/*",
+				"interest":       "/api/v1/interest/*",
+				"credit_limits":  "/api/v1/credit-limits/*",
+				"reconciliation": "/api/v1/admin/reconciliation/*",
+				"projections":    "/api/v1/admin/projections/*",
+				"dead_letters":   "/api/v1/admin/dead-letters/*",
 			},
 			"features": map[string]interface{}{
 				"event_sourcing":         true,